@@ -0,0 +1,125 @@
+package fcli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/berquerant/fcli/internal/logger"
+	"github.com/berquerant/fcli/internal/shellwords"
+)
+
+var (
+	ErrCLIBatchFailure = errors.New("cli batch failure")
+)
+
+// BatchMode selects how Batch handles the failed lines.
+type BatchMode int
+
+const (
+	// BatchStopOnError stops at the first failed line and returns LineError.
+	BatchStopOnError BatchMode = iota
+	// BatchContinueOnError runs all lines and returns BatchError if some lines failed.
+	BatchContinueOnError
+)
+
+// LineError is the error of the line of Batch.
+type LineError struct {
+	// Line is the line number, starting from 1.
+	Line int
+	// Text is the line.
+	Text string
+	// Err is the error returned by the line.
+	Err error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %s: %v", e.Line, e.Text, e.Err)
+}
+
+func (e *LineError) Unwrap() error { return e.Err }
+
+// BatchError is the error returned by Batch in BatchContinueOnError
+// if some lines failed.
+type BatchError struct {
+	// Total is the number of the executed lines.
+	Total int
+	// Errors is the errors of the failed lines, in order.
+	Errors []*LineError
+}
+
+func (e *BatchError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%v %d of %d lines failed", ErrCLIBatchFailure, len(e.Errors), e.Total)
+	for _, x := range e.Errors {
+		fmt.Fprintf(&b, "\n%v", x)
+	}
+	return b.String()
+}
+
+func (e *BatchError) Unwrap() error { return ErrCLIBatchFailure }
+
+// ExitCode returns the exit status of the first failed line.
+func (e *BatchError) ExitCode() int {
+	if len(e.Errors) == 0 {
+		return ExitCodeSuccess
+	}
+	return ExitCode(e.Errors[0])
+}
+
+func (s *cliMap) Batch(ctx context.Context, r io.Reader, mode BatchMode) error {
+	ctx = NoExit(ctx)
+	var (
+		scanner = bufio.NewScanner(r)
+		lineNum int
+		total   int
+		errs    []*LineError
+	)
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("%w line %d %v", ErrCLIBatchFailure, lineNum, err)
+		}
+		total++
+		err := s.batchLine(ctx, line)
+		if err == nil {
+			continue
+		}
+		logger.Debug("Batch line %d %s returned error %v", lineNum, line, err)
+		lineErr := &LineError{
+			Line: lineNum,
+			Text: line,
+			Err:  err,
+		}
+		if mode == BatchStopOnError || errors.Is(err, ErrCLIInterrupted) {
+			return lineErr
+		}
+		errs = append(errs, lineErr)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%w read line %d %v", ErrCLIBatchFailure, lineNum+1, err)
+	}
+	if len(errs) > 0 {
+		return &BatchError{
+			Total:  total,
+			Errors: errs,
+		}
+	}
+	return nil
+}
+
+func (s *cliMap) batchLine(ctx context.Context, line string) error {
+	args, err := shellwords.Split(line)
+	if err != nil {
+		return fmt.Errorf("%w split %v", ErrCLIBatchFailure, err)
+	}
+	_, err = s.start(ctx, args...)
+	return err
+}
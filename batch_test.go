@@ -0,0 +1,117 @@
+package fcli_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/berquerant/fcli"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	batchTestRecorder []string
+	errBatchTest      = errors.New("batch test")
+)
+
+func batchTestEcho(msg string) {
+	batchTestRecorder = append(batchTestRecorder, msg)
+}
+
+func batchTestFail() (int, error) { return 10, errBatchTest }
+
+func TestCLIBatch(t *testing.T) {
+	const script = `# runbook
+echo -msg first
+
+  echo -msg 'second line'
+fail
+unknown
+echo -msg "last"
+`
+
+	for _, tc := range []struct {
+		name     string
+		input    string
+		mode     fcli.BatchMode
+		want     []string
+		errLines []int
+		exitCode int
+	}{
+		{
+			name:  "empty",
+			input: "",
+			mode:  fcli.BatchStopOnError,
+			want:  []string{},
+		},
+		{
+			name: "success",
+			input: `# comment
+echo -msg a
+echo -msg b`,
+			mode: fcli.BatchStopOnError,
+			want: []string{"a", "b"},
+		},
+		{
+			name:     "stop on error",
+			input:    script,
+			mode:     fcli.BatchStopOnError,
+			want:     []string{"first", "second line"},
+			errLines: []int{5},
+			exitCode: 10,
+		},
+		{
+			name:     "continue on error",
+			input:    script,
+			mode:     fcli.BatchContinueOnError,
+			want:     []string{"first", "second line", "last"},
+			errLines: []int{5, 6},
+			exitCode: 10,
+		},
+		{
+			name:     "unterminated quote",
+			input:    "echo -msg 'a\necho -msg b",
+			mode:     fcli.BatchContinueOnError,
+			want:     []string{"b"},
+			errLines: []int{1},
+			exitCode: fcli.ExitCodeError,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			batchTestRecorder = []string{}
+			cli := fcli.NewCLI("tool")
+			assert.Nil(t, cli.Add(batchTestEcho, fcli.WithCommandName("echo")))
+			assert.Nil(t, cli.Add(batchTestFail, fcli.WithCommandName("fail")))
+
+			err := cli.Batch(context.Background(), strings.NewReader(tc.input), tc.mode)
+			assert.Equal(t, tc.want, batchTestRecorder)
+			assert.Equal(t, tc.exitCode, fcli.ExitCode(err))
+			if len(tc.errLines) == 0 {
+				assert.Nil(t, err)
+				return
+			}
+			var lineErrs []*fcli.LineError
+			if tc.mode == fcli.BatchStopOnError {
+				var lineErr *fcli.LineError
+				if !assert.ErrorAs(t, err, &lineErr) {
+					return
+				}
+				lineErrs = []*fcli.LineError{lineErr}
+			} else {
+				var batchErr *fcli.BatchError
+				if !assert.ErrorAs(t, err, &batchErr) {
+					return
+				}
+				assert.ErrorIs(t, err, fcli.ErrCLIBatchFailure)
+				lineErrs = batchErr.Errors
+			}
+			got := make([]int, len(lineErrs))
+			for i, x := range lineErrs {
+				got[i] = x.Line
+			}
+			assert.Equal(t, tc.errLines, got)
+		})
+	}
+}
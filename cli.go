@@ -4,80 +4,367 @@ package fcli
 import (
 	"context"
 	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/berquerant/fcli/internal/logger"
+	"github.com/berquerant/fcli/internal/suggest"
 )
 
 var (
 	ErrCLINotEnoughArguments = errors.New("not enough arguments")
 	ErrCLICommandNotFound    = errors.New("command not found")
+	ErrCLICannotMount        = errors.New("cannot mount")
+	ErrCLIAmbiguousCommand   = errors.New("ambiguous command")
+	ErrCLIDuplicateCommand   = errors.New("duplicate command")
+	ErrCLIMethodNotFound     = errors.New("method not found")
 
 	// NilUsage is noop.
 	// Disable Usage of CLI by CLI.Usage(NilUsage).
 	NilUsage = func() {}
-	// DefaultOnError prints the error, usage and returns the error.
+	// DefaultOnError prints the error, suggestions, usage and returns the error.
+	// Returns the error only if help is requested by -h because the usage is already printed,
+	// the function returned the exit status without the error, see ExitError,
+	// or the plugin exited with non-zero status because the plugin reports its errors.
+	// DefaultOnError prints to os.Stderr, the default of CLI prints to the stderr set by WithStderr or WithStreams.
 	DefaultOnError = func(err error) int {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		return Cusage | Cerror
+		return writeError(os.Stderr, err)
 	}
 )
 
+// writeError prints err and the suggestions to w like DefaultOnError.
+func writeError(w io.Writer, err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return Cerror
+	}
+	if exitErr := (*ExitError)(nil); errors.As(err, &exitErr) && exitErr.Err == nil {
+		return Cerror
+	}
+	if pluginErr := (*PluginError)(nil); errors.As(err, &pluginErr) && pluginErr.Code >= 0 {
+		return Cerror
+	}
+	fmt.Fprintf(w, "Error: %v\n", err)
+	writeSuggestions(w, err)
+	return Cusage | Cerror
+}
+
 type (
 	UsageFunc = func()
+	// FallbackFunc handles the arguments that do not match any command.
+	// arguments includes the unmatched command name.
+	FallbackFunc = func(ctx context.Context, arguments []string) error
 )
 
+// AmbiguousCommandError is the error returned if the prefix of the command name matches multiple commands.
+type AmbiguousCommandError struct {
+	// Path is the names of the CLI and the groups.
+	Path string
+	// Name is the given command name.
+	Name string
+	// Candidates is the names of the commands that have Name as the prefix.
+	Candidates []string
+}
+
+func (e *AmbiguousCommandError) Error() string {
+	return fmt.Sprintf("%v %s %s candidates %s", ErrCLIAmbiguousCommand, e.Path, e.Name, strings.Join(e.Candidates, ","))
+}
+
+func (e *AmbiguousCommandError) Unwrap() error { return ErrCLIAmbiguousCommand }
+
+// CommandNotFoundError is the error returned if the command is not found.
+type CommandNotFoundError struct {
+	// Path is the names of the CLI and the groups.
+	Path string
+	// Name is the given command name.
+	Name string
+	// Suggestions is the names of the commands close to Name, the closest first.
+	Suggestions []string
+}
+
+func (e *CommandNotFoundError) Error() string {
+	return fmt.Sprintf("%v %s %s", ErrCLICommandNotFound, e.Path, e.Name)
+}
+
+func (e *CommandNotFoundError) Unwrap() error { return ErrCLICommandNotFound }
+
+// writeSuggestions prints the suggestions in err if exist.
+func writeSuggestions(w io.Writer, err error) {
+	var (
+		ss         []string
+		commandErr *CommandNotFoundError
+		flagErr    *UnknownFlagError
+	)
+	switch {
+	case errors.As(err, &commandErr):
+		ss = commandErr.Suggestions
+	case errors.As(err, &flagErr):
+		ss = make([]string, len(flagErr.Suggestions))
+		for i, x := range flagErr.Suggestions {
+			ss[i] = "-" + x
+		}
+	}
+	if len(ss) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Did you mean this?\n")
+	for _, x := range ss {
+		fmt.Fprintf(w, "\t%s\n", x)
+	}
+}
+
 const (
 	Cusage = 1 << iota // print usage
 	Cerror             // return error
 )
 
 // CLI is the function-based subcommands set.
+// CLI is safe for concurrent use, the functions can be called concurrently
+// and each call parses the arguments into the new flags.
 type CLI interface {
 	// Start parses arguments and calls proper function.
 	// if arguments is nil, reads os.Args.
 	Start(arguments ...string) error
 	StartWithContext(ctx context.Context, arguments ...string) error
+	// Run is Start that returns the exit status by ExitCode instead of the error.
+	// Run does not exit even if ErrorHandling of the function is flag.ExitOnError,
+	// so the caller should exit, like os.Exit(cli.Run()).
+	Run(arguments ...string) int
+	RunWithContext(ctx context.Context, arguments ...string) int
 	// Add adds a subcommand.
 	// See NewTargetFunction.
+	// Returns ErrCLIDuplicateCommand if the command or the group of the same name exists.
+	// WithReplace(true) replaces the command of the same name.
 	Add(f any, opt ...Option) error
+	// AddMethods adds the exported methods of obj as subcommands, or the methods named names if given.
+	// The command names are the method names, the flags and the docs come from the method declarations.
+	// Without names, the methods that cannot be the commands like String() string are skipped.
+	// Adds none of the methods if any of them cannot be added.
+	// Returns ErrCLIMethodNotFound if obj does not have the method named by names.
+	AddMethods(obj any, names ...string) error
+	// Configure sets the options of the CLI, like NewCLI.
+	Configure(opt ...Option)
 	// Usage sets a function to print usage.
 	Usage(func())
 	// OnError sets a function called when command function returned an error.
 	// If onError return Cusage then print usage.
 	// If onError return Cerror then return the error.
 	OnError(onError func(error) int)
+	// Group returns the subcommands set named name, creates it if not exists.
+	// The group is dispatched by the first argument and the rest are passed to it,
+	// like `tool db migrate`.
+	// If the command named name exists, the group is not added not to shadow the command:
+	// the returned group is detached from the CLI and its commands are never called,
+	// reported by Validate. Use AddGroup to get the error.
+	Group(name string) CLI
+	// AddGroup is Group but returns ErrCLIDuplicateCommand if the command named name exists.
+	AddGroup(name string) (CLI, error)
+	// Mount adds cli as the group named name.
+	// cli should be created by NewCLI and not mounted yet,
+	// and name should not be used by the commands and the groups.
+	// Returns ErrCLICannotMount if cannot mount.
+	Mount(name string, cli CLI) error
+	// PrefixMatch enables dispatching by the unique prefix of the command names and the aliases,
+	// e.g. `do gr` calls greet.
+	// Enabled in the groups if enabled in the parent.
+	// Returns AmbiguousCommandError if the prefix matches multiple commands.
+	PrefixMatch(enabled bool)
+	// AddGlobalFlag adds a flag parsed before the command name, like `tool -v greet`.
+	// value is the default value and the type of value selects the kind of the flag, see NewFlagFactory.
+	// usage is printed in usage like flag.String.
+	// The parsed value, or value if not set, is available by GlobalFlag from the context passed to the function,
+	// and is injected into the parameter of the function that has the same name and type
+	// unless the flag of the function is set.
+	// Returns ErrCLIInvalidGlobalFlag if the type is not supported or the name is already defined.
+	AddGlobalFlag(name string, value any, usage string) error
+	// Default sets the command or the group called if no command name is given
+	// or the first argument is a flag, like `tool` or `tool -name world`.
+	// The global flags are parsed until the first unknown flag,
+	// the rest are passed to the default command, like `tool -v -name world`.
+	// Validate reports name if it is not a command, a group or an alias.
+	Default(name string)
+	// Fallback sets a function called if the command is not found
+	// instead of returning ErrCLICommandNotFound.
+	Fallback(f FallbackFunc)
+	// Plugins enables the external commands:
+	// if the command is not found, executes `<path>-<command>` in PATH with the rest arguments,
+	// like tool-deploy for `tool deploy` and tool-db-dump for `tool db dump`.
+	// Stdin, stdout and stderr are passed through,
+	// and PluginError has the exit status of the plugin.
+	// The plugins found in PATH are listed in usage.
+	// Called before the fallback. Enabled in the groups if enabled in the parent.
+	Plugins(enabled bool)
+	// AddHelpTopic adds a page printed by `tool help name`.
+	//
+	// The help command is registered automatically:
+	// `tool help` prints the commands with the summaries,
+	// `tool help greet` prints the doc and the flags of greet.
+	// The commands, the groups and the aliases precede the topics of the same name,
+	// reported by Validate.
+	AddHelpTopic(name, text string)
+	// Order sets the order of the commands in usage.
+	// Default is OrderAlphabetical, the groups inherit the order of the parent.
+	Order(order CommandOrder)
+	// CategoryOrder sets the order of the categories set by WithCategory in usage.
+	// The categories not in categories follow alphabetically.
+	// If any command has the category, the commands without the category are listed
+	// under CategoryOther, the last by default.
+	// The groups inherit the order of the parent.
+	CategoryOrder(categories ...string)
+	// WriteCompletion writes the completion script for shell: bash, zsh or fish.
+	// The script calls the hidden command `__complete` to list the candidates
+	// from the commands and the flags.
+	// Returns ErrCLIUnsupportedShell if shell is not supported.
+	WriteCompletion(w io.Writer, shell string) error
+	// GenerateDocs writes the documents of the CLI, the groups and the commands into dir
+	// in format: man or markdown.
+	// One file is written for each of them, like tool-db-migrate.1.
+	// The hidden command `__docs FORMAT DIR` calls this.
+	// Returns ErrCLIUnsupportedDocFormat if format is not supported.
+	GenerateDocs(dir, format string) error
+	// Use adds the interceptors wrapping the calls of the functions in the CLI and the groups.
+	// The interceptors of the parent are called first,
+	// the interceptors of the function set by WithInterceptors are called last.
+	Use(interceptor ...Interceptor)
+	// HandleSignals enables canceling the context passed to the function on SIGINT or SIGTERM.
+	// After the signal, waits gracePeriod for the function to return
+	// and returns InterruptedError.
+	// Exits with the status 128 + signal number on the second signal.
+	// Applies to the functions, the plugins and the fallback of the CLI and the groups,
+	// the setting of the nearest group wins.
+	HandleSignals(gracePeriod time.Duration)
+	// REPL reads the lines from stdin, splits them like a shell and calls the commands
+	// until EOF, `exit` or `quit`.
+	// Keeps running even if the command fails, the error is reported by OnError.
+	// If stdin is a terminal, the line can be edited and Tab completes the commands and the flags.
+	// If historyFile is not empty, the lines are read from and appended to it.
+	// Returns ErrCLIREPLFailure if failed to read the lines or the history.
+	REPL(ctx context.Context, historyFile string) error
+	// Batch reads the commands from r one per line, splits them like a shell and calls them
+	// with ctx in order.
+	// Blank lines and lines starting with # are skipped.
+	// Does not exit even if ErrorHandling of the function is flag.ExitOnError.
+	// Returns LineError of the first failed line in BatchStopOnError,
+	// returns BatchError of all failed lines in BatchContinueOnError.
+	// Interruption by HandleSignals stops the batch in both modes.
+	Batch(ctx context.Context, r io.Reader, mode BatchMode) error
+	// Validate checks the CLI and the groups for the clashes of the names:
+	// between the commands, the groups and the aliases, with the reserved names like help,
+	// and between the flags of the functions and the global flags of the different types.
+	// Returns ValidationError listing all problems.
+	// Intended to be called in the tests.
+	Validate() error
+	// Commands returns the metadata of the commands of the CLI and the groups in usage order,
+	// including the hidden commands.
+	Commands() []CommandInfo
+	// WriteSpec writes the CLI, the groups and the commands as JSON, see Spec.
+	// The flags include the JSON Schema of the values,
+	// the custom flags can provide it by CustomFlagJSONSchemaer.
+	// The hidden command `__spec` calls this.
+	WriteSpec(w io.Writer) error
 }
 
+// NewCLI returns a new CLI named name.
+//
+// WithStdin, WithStdout and WithStderr set the streams of the CLI:
+// usage, errors, help, completions and the spec are printed to them,
+// the flags of the functions report the errors to the stderr
+// and the functions get them by Stdin, Stdout and Stderr from the context.
+// The groups inherit the streams from the parent unless set.
+// WithDescription sets the text of the CLI or the group printed in usage and the documents.
+//
+// WithErrorHandling, WithCategory and WithHidden are the defaults of the commands added after,
+// in the CLI and the groups.
+// The options of Add override them.
+// WithInterceptors is the same as Use.
+// The other options are for the commands and ignored, reported by Validate.
 func NewCLI(name string, opt ...Option) CLI {
-	s := &cliMap{
+	s := newCLIMap(name)
+	s.configure(opt...)
+	return s
+}
+
+func newCLIMap(name string) *cliMap {
+	return &cliMap{
 		name:     name,
-		commands: map[string]TargetFunction{},
-		onError:  DefaultOnError,
+		commands: map[string]*targetFunction{},
+		aliases:  map[string]string{},
+		groups:   map[string]*cliMap{},
+		topics:   map[string]string{},
+		config:   NewConfigBuilder().Build(),
 	}
-	s.usage = s.defaultUsage
-	return s
 }
 
+// cliMu guards the fields of all cliMaps, including the groups mounted from other CLIs.
+// Not held while calling the functions, usage, onError and the fallback,
+// so they can modify the CLI.
+var cliMu sync.RWMutex
+
 type cliMap struct {
-	name     string
-	usage    func()
-	onError  func(error) int
-	commands map[string]TargetFunction
+	name         string
+	parent       *cliMap
+	config       *Config
+	usage        func()          // nil means the default, see usageFunc
+	onError      func(error) int // nil means the default, see onErrorFunc
+	commands     map[string]*targetFunction
+	aliases      map[string]string // alias to command name
+	groups       map[string]*cliMap
+	globals      []*globalFlag
+	topics       map[string]string
+	interceptors []Interceptor // wrap the functions of s and the groups
+	names        []string      // commands and groups in registration order
+	order        CommandOrder
+	// categoryOrder is the order of the categories in usage, nil means inherit
+	categoryOrder []string
+	prefixMatch   bool
+	plugins       bool
+	// defaultCommand is called if no command name is given.
+	defaultCommand string
+	fallback       FallbackFunc
+	handleSignals  bool
+	gracePeriod    time.Duration
+	// problems is found while building the CLI, reported by Validate
+	problems []string
+}
+
+func (s *cliMap) root() *cliMap {
+	x := s
+	for x.parent != nil {
+		x = x.parent
+	}
+	return x
+}
+
+// path returns the names from the root to s separated by space.
+func (s *cliMap) path() string {
+	if s.parent == nil {
+		return s.name
+	}
+	return fmt.Sprintf("%s %s", s.parent.path(), s.name)
 }
 
 func (s *cliMap) StartWithContext(ctx context.Context, arguments ...string) error {
-	if err := s.start(ctx, arguments...); err != nil {
-		r := s.onError(err)
-		if r&Cusage != 0 {
-			s.usage()
-		}
-		if r&Cerror != 0 {
-			return err
-		}
+	level, err := s.start(ctx, arguments...)
+	if err == nil {
+		return nil
+	}
+	cliMu.RLock()
+	var (
+		onError = s.onErrorFunc(ctx)
+		usage   = level.usageFunc(ctx)
+	)
+	cliMu.RUnlock()
+	r := onError(err)
+	if r&Cusage != 0 {
+		usage()
+	}
+	if r&Cerror != 0 {
+		return err
 	}
 	return nil
 }
@@ -86,50 +373,491 @@ func (s *cliMap) Start(arguments ...string) error {
 	return s.StartWithContext(context.Background(), arguments...)
 }
 
-func (s *cliMap) start(ctx context.Context, arguments ...string) error {
-	args, ok := func() ([]string, bool) {
-		if len(arguments) == 0 {
-			if len(os.Args) < 2 {
-				return nil, false
-			}
-			return os.Args[1:], true
+func (s *cliMap) start(ctx context.Context, arguments ...string) (*cliMap, error) {
+	if arguments == nil {
+		arguments = os.Args[1:]
+	}
+	return s.dispatch(ctx, arguments)
+}
+
+// dispatch calls the function or the group selected by args[0].
+// Returns the cliMap that failed to dispatch and the error.
+func (s *cliMap) dispatch(ctx context.Context, args []string) (*cliMap, error) {
+	if s.hasGlobalFlags() {
+		values, rest, err := s.parseGlobalFlags(ctx, args)
+		if err != nil {
+			return s, err
 		}
-		return arguments, true
-	}()
-	if !ok {
-		return ErrCLINotEnoughArguments
+		ctx = withGlobalFlags(ctx, values)
+		args = rest
 	}
 
-	cmd, ok := s.commands[args[0]]
-	if !ok {
-		return fmt.Errorf("%w %s", ErrCLICommandNotFound, args[0])
+	cliMu.RLock()
+	target, err := s.lookup(args)
+	st := s.streams(ctx)
+	cliMu.RUnlock()
+	if err != nil {
+		return s, err
+	}
+	ctx = withStreams(ctx, st)
+	switch {
+	case target.builtin == helpCommandName:
+		return s.help(st.stdout, target.args)
+	case target.builtin == completeCommandName:
+		s.writeCompletions(st.stdout, target.args)
+		return s, nil
+	case target.builtin == docsCommandName:
+		return s, s.generateDocs(target.args)
+	case target.builtin == specCommandName:
+		return s, s.WriteSpec(st.stdout)
+	case target.pluginFile != "":
+		return s, s.callWithSignals(ctx, func(ctx context.Context) error {
+			return callPlugin(ctx, target.pluginName, target.pluginFile, target.args)
+		})
+	case target.fallback != nil:
+		return s, s.callWithSignals(ctx, func(ctx context.Context) error {
+			return target.fallback(ctx, target.args)
+		})
+	case target.group != nil:
+		return target.group.dispatch(ctx, target.args)
+	default:
+		return s, s.callWithSignals(ctx, func(ctx context.Context) error {
+			return target.command.call(ctx, target.args, target.interceptors)
+		})
+	}
+}
+
+// dispatchTarget is the destination of dispatch.
+type dispatchTarget struct {
+	// args is the arguments passed to the destination.
+	args         []string
+	builtin      string
+	pluginName   string
+	pluginFile   string
+	fallback     FallbackFunc
+	group        *cliMap
+	command      *targetFunction
+	interceptors []Interceptor
+}
+
+// lookup selects the destination of args.
+// Requires cliMu.
+func (s *cliMap) lookup(args []string) (*dispatchTarget, error) {
+	byDefault := s.defaultCommand != "" && (len(args) == 0 || strings.HasPrefix(args[0], "-"))
+	if byDefault {
+		logger.Debug("Dispatch %v to default command %s in %s", args, s.defaultCommand, s.path())
+		args = append([]string{s.defaultCommand}, args...)
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("%w %s", ErrCLINotEnoughArguments, s.path())
 	}
+	switch args[0] {
+	case helpCommandName, completeCommandName, docsCommandName, specCommandName:
+		return &dispatchTarget{
+			args:    args[1:],
+			builtin: args[0],
+		}, nil
+	}
+
+	name, err := s.resolve(args[0])
+	if err != nil && errors.Is(err, ErrCLICommandNotFound) {
+		if file, ok := s.lookupPlugin(args[0]); ok {
+			return &dispatchTarget{
+				args:       args[1:],
+				pluginName: args[0],
+				pluginFile: file,
+			}, nil
+		}
+	}
+	if err != nil {
+		if s.fallback != nil && errors.Is(err, ErrCLICommandNotFound) {
+			logger.Debug("Call fallback of %s with %#v", s.path(), args)
+			return &dispatchTarget{
+				args:     args,
+				fallback: s.fallback,
+			}, nil
+		}
+		if byDefault && errors.Is(err, ErrCLICommandNotFound) {
+			// not to suggest the names for the name not given by the user
+			return nil, fmt.Errorf("%w %s default command %s", ErrCLICommandNotFound, s.path(), s.defaultCommand)
+		}
+		return nil, err
+	}
+	if g, ok := s.groups[name]; ok {
+		logger.Debug("Dispatch %s to group %s", args[0], g.path())
+		return &dispatchTarget{
+			args:  args[1:],
+			group: g,
+		}, nil
+	}
+	cmd := s.commands[name]
 	logger.Debug("Call %s with %#v", cmd.Name(), args[1:])
-	return cmd.CallWithContext(ctx, args[1:])
+	return &dispatchTarget{
+		args:         args[1:],
+		command:      cmd,
+		interceptors: s.interceptorChain(),
+	}, nil
 }
 
-func (s *cliMap) defaultUsage() {
-	var (
-		i  int
-		ss = make([]string, len(s.commands))
-	)
+// resolve returns the name of the command or the group selected by arg.
+func (s *cliMap) resolve(arg string) (string, error) {
+	if _, ok := s.groups[arg]; ok {
+		return arg, nil
+	}
+	if _, ok := s.commands[arg]; ok {
+		return arg, nil
+	}
+	if name, ok := s.aliases[arg]; ok {
+		return name, nil
+	}
+	if !s.prefixMatchEnabled() {
+		return "", s.commandNotFound(arg)
+	}
+
+	found := map[string]bool{}
+	for k := range s.groups {
+		if strings.HasPrefix(k, arg) {
+			found[k] = true
+		}
+	}
 	for k := range s.commands {
-		ss[i] = k
-		i++
+		if strings.HasPrefix(k, arg) && !s.isHidden(k) {
+			found[k] = true
+		}
+	}
+	for k, v := range s.aliases {
+		if strings.HasPrefix(k, arg) && !s.isHidden(v) {
+			found[v] = true
+		}
+	}
+	candidates := make([]string, 0, len(found))
+	for k := range found {
+		candidates = append(candidates, k)
+	}
+	sort.Strings(candidates)
+	logger.Debug("Prefix %s matched %v in %s", arg, candidates, s.path())
+
+	switch len(candidates) {
+	case 0:
+		return "", s.commandNotFound(arg)
+	case 1:
+		return candidates[0], nil
+	default:
+		return "", &AmbiguousCommandError{
+			Path:       s.path(),
+			Name:       arg,
+			Candidates: candidates,
+		}
 	}
-	sort.Strings(ss)
-	fmt.Fprintf(os.Stderr, "Usage: %s {%s}\n", s.name, strings.Join(ss, ","))
+}
+
+func (s *cliMap) commandNotFound(arg string) error {
+	names := []string{}
+	for k := range s.groups {
+		names = append(names, k)
+	}
+	for k := range s.commands {
+		if !s.isHidden(k) {
+			names = append(names, k)
+		}
+	}
+	for k, v := range s.aliases {
+		if !s.isHidden(v) {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	return &CommandNotFoundError{
+		Path:        s.path(),
+		Name:        arg,
+		Suggestions: suggest.Rank(arg, names),
+	}
+}
+
+func (s *cliMap) prefixMatchEnabled() bool {
+	for x := s; x != nil; x = x.parent {
+		if x.prefixMatch {
+			return true
+		}
+	}
+	return false
+}
+
+// usageFunc returns the function set by Usage,
+// the default prints usage to the stderr of the streams with ctx.
+// Requires cliMu.
+func (s *cliMap) usageFunc(ctx context.Context) func() {
+	if s.usage != nil {
+		return s.usage
+	}
+	w := s.streams(ctx).stderr
+	return func() {
+		cliMu.RLock()
+		defer cliMu.RUnlock()
+		s.writeUsage(w)
+	}
+}
+
+// onErrorFunc returns the function set by OnError,
+// the default prints the error to the stderr of the streams with ctx like DefaultOnError.
+// Requires cliMu.
+func (s *cliMap) onErrorFunc(ctx context.Context) func(error) int {
+	if s.onError != nil {
+		return s.onError
+	}
+	w := s.streams(ctx).stderr
+	return func(err error) int {
+		return writeError(w, err)
+	}
+}
+
+func (s *cliMap) Configure(opt ...Option) {
+	cliMu.Lock()
+	defer cliMu.Unlock()
+	s.configure(opt...)
+}
+
+// configure applies the options of the CLI in opt to the config.
+// Requires cliMu.
+func (s *cliMap) configure(opt ...Option) {
+	c := NewConfigBuilder().Build()
+	c.Apply(opt...)
+	if c.ErrorHandling.IsModified() {
+		s.config.ErrorHandling.Set(c.ErrorHandling.Get())
+	}
+	if c.Category.IsModified() {
+		s.config.Category.Set(c.Category.Get())
+	}
+	if c.Hidden.IsModified() {
+		s.config.Hidden.Set(c.Hidden.Get())
+	}
+	if c.Interceptors.IsModified() {
+		s.interceptors = append(s.interceptors, c.Interceptors.Get()...)
+	}
+	if c.Stdin.IsModified() {
+		s.config.Stdin.Set(c.Stdin.Get())
+	}
+	if c.Stdout.IsModified() {
+		s.config.Stdout.Set(c.Stdout.Get())
+	}
+	if c.Stderr.IsModified() {
+		s.config.Stderr.Set(c.Stderr.Get())
+	}
+	if c.Description.IsModified() {
+		s.config.Description.Set(c.Description.Get())
+	}
+	for _, x := range []struct {
+		name string
+		item interface{ IsModified() bool }
+	}{
+		{name: "CommandName", item: c.CommandName},
+		{name: "Aliases", item: c.Aliases},
+		{name: "Replace", item: c.Replace},
+		{name: "Deprecated", item: c.Deprecated},
+		{name: "DeprecatedFlags", item: c.DeprecatedFlags},
+		{name: "RenamedFlags", item: c.RenamedFlags},
+		{name: "ParamNames", item: c.ParamNames},
+		{name: "Doc", item: c.Doc},
+	} {
+		if x.item.IsModified() {
+			logger.Debug("Ignore option %s of %s, it is for the commands", x.name, s.path())
+			s.problems = append(s.problems, fmt.Sprintf("option With%s is for the commands, ignored", x.name))
+		}
+	}
+}
+
+// commandOptions returns the defaults of the commands inherited from the root, followed by opt.
+// Requires cliMu.
+func (s *cliMap) commandOptions(opt []Option) []Option {
+	var chain []*cliMap
+	for x := s; x != nil; x = x.parent {
+		chain = append(chain, x)
+	}
+	options := []Option{}
+	for i := len(chain) - 1; i >= 0; i-- {
+		c := chain[i].config
+		if c.ErrorHandling.IsModified() {
+			options = append(options, WithErrorHandling(c.ErrorHandling.Get()))
+		}
+		if c.Category.IsModified() {
+			options = append(options, WithCategory(c.Category.Get()))
+		}
+		if c.Hidden.IsModified() {
+			options = append(options, WithHidden(c.Hidden.Get()))
+		}
+	}
+	return append(options, opt...)
 }
 
 func (s *cliMap) Add(f any, opt ...Option) error {
-	t, err := NewTargetFunction(f, opt...)
+	cliMu.RLock()
+	opt = s.commandOptions(opt)
+	cliMu.RUnlock()
+	t, err := newTargetFunction(f, opt...)
 	if err != nil {
 		return err
 	}
-	logger.Debug("Add command %s %#v to %s", t.Name(), f, s.name)
+	cliMu.Lock()
+	defer cliMu.Unlock()
+	return s.addCommand(t)
+}
+
+// addCommand registers t.
+// Requires cliMu.
+func (s *cliMap) addCommand(t *targetFunction) error {
+	if err := s.checkCommand(t); err != nil {
+		return err
+	}
+	s.registerCommand(t)
+	return nil
+}
+
+// checkCommand returns ErrCLIDuplicateCommand if t cannot be added.
+// Requires cliMu.
+func (s *cliMap) checkCommand(t *targetFunction) error {
+	if _, ok := s.groups[t.Name()]; ok {
+		return fmt.Errorf("%w %s %s already added as a group", ErrCLIDuplicateCommand, s.path(), t.Name())
+	}
+	if _, ok := s.commands[t.Name()]; ok && !t.config.Replace.Get() {
+		return fmt.Errorf("%w %s %s already added", ErrCLIDuplicateCommand, s.path(), t.Name())
+	}
+	return nil
+}
+
+// registerCommand registers t checked by checkCommand.
+// Requires cliMu.
+func (s *cliMap) registerCommand(t *targetFunction) {
+	if old, ok := s.commands[t.Name()]; ok {
+		logger.Debug("Replace command %s in %s", t.Name(), s.path())
+		for _, alias := range old.Aliases() {
+			if s.aliases[alias] == old.Name() {
+				delete(s.aliases, alias)
+			}
+		}
+	}
+	logger.Debug("Add command %s %s to %s", t.Name(), t.fname.FullName(), s.path())
+	s.register(t.Name())
 	s.commands[t.Name()] = t
+	for _, alias := range t.Aliases() {
+		s.aliases[alias] = t.Name()
+	}
+}
+
+func (s *cliMap) Group(name string) CLI {
+	cliMu.Lock()
+	defer cliMu.Unlock()
+	g, err := s.group(name)
+	if err == nil {
+		return g
+	}
+	msg := fmt.Sprintf("group %s clashes with command %s, not added", name, name)
+	logger.Info("%s: %s", s.path(), msg)
+	s.problems = append(s.problems, msg)
+	g = newCLIMap(name)
+	g.parent = s
+	return g
+}
+
+func (s *cliMap) AddGroup(name string) (CLI, error) {
+	cliMu.Lock()
+	defer cliMu.Unlock()
+	g, err := s.group(name)
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// group returns the group named name, creates it if not exists.
+// Returns ErrCLIDuplicateCommand not to shadow the command named name, like Add.
+// Requires cliMu.
+func (s *cliMap) group(name string) (*cliMap, error) {
+	if g, ok := s.groups[name]; ok {
+		return g, nil
+	}
+	if _, ok := s.commands[name]; ok {
+		return nil, fmt.Errorf("%w %s %s already added as a command", ErrCLIDuplicateCommand, s.path(), name)
+	}
+	g := newCLIMap(name)
+	g.parent = s
+	logger.Debug("Add group %s to %s", name, s.path())
+	s.register(name)
+	s.groups[name] = g
+	return g, nil
+}
+
+func (s *cliMap) Mount(name string, cli CLI) error {
+	g, ok := cli.(*cliMap)
+	if !ok {
+		return fmt.Errorf("%w %s not created by NewCLI", ErrCLICannotMount, name)
+	}
+	cliMu.Lock()
+	defer cliMu.Unlock()
+	if g.parent != nil {
+		return fmt.Errorf("%w %s already mounted on %s", ErrCLICannotMount, name, g.parent.path())
+	}
+	for x := s; x != nil; x = x.parent {
+		if x == g {
+			return fmt.Errorf("%w %s onto its descendant %s", ErrCLICannotMount, name, s.path())
+		}
+	}
+	if _, ok := s.commands[name]; ok {
+		return fmt.Errorf("%w %s already added as a command to %s", ErrCLICannotMount, name, s.path())
+	}
+	if _, ok := s.groups[name]; ok {
+		return fmt.Errorf("%w %s already added as a group to %s", ErrCLICannotMount, name, s.path())
+	}
+	g.name = name
+	g.parent = s
+	logger.Debug("Mount group %s to %s", name, s.path())
+	s.register(name)
+	s.groups[name] = g
 	return nil
 }
 
-func (s *cliMap) Usage(usage func())              { s.usage = usage }
-func (s *cliMap) OnError(onError func(error) int) { s.onError = onError }
+// register records the registration order of the command or the group.
+func (s *cliMap) register(name string) {
+	if _, ok := s.commands[name]; ok {
+		return
+	}
+	if _, ok := s.groups[name]; ok {
+		return
+	}
+	s.names = append(s.names, name)
+}
+
+func (s *cliMap) PrefixMatch(enabled bool) {
+	cliMu.Lock()
+	defer cliMu.Unlock()
+	s.prefixMatch = enabled
+}
+
+func (s *cliMap) Order(order CommandOrder) {
+	cliMu.Lock()
+	defer cliMu.Unlock()
+	s.order = order
+}
+
+func (s *cliMap) Default(name string) {
+	cliMu.Lock()
+	defer cliMu.Unlock()
+	s.defaultCommand = name
+}
+
+func (s *cliMap) Fallback(f FallbackFunc) {
+	cliMu.Lock()
+	defer cliMu.Unlock()
+	s.fallback = f
+}
+
+func (s *cliMap) Usage(usage func()) {
+	cliMu.Lock()
+	defer cliMu.Unlock()
+	s.usage = usage
+}
+
+func (s *cliMap) OnError(onError func(error) int) {
+	cliMu.Lock()
+	defer cliMu.Unlock()
+	s.onError = onError
+}
@@ -0,0 +1,1026 @@
+package fcli_test
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/berquerant/fcli"
+	"github.com/stretchr/testify/assert"
+)
+
+type cliTestRecorder struct {
+	calls []string
+}
+
+func (s *cliTestRecorder) record(v string) { s.calls = append(s.calls, v) }
+func (s *cliTestRecorder) reset()          { s.calls = nil }
+
+var cliTestRecorderInstance cliTestRecorder
+
+func cliTestMigrate(version int) {
+	cliTestRecorderInstance.record("migrate")
+}
+
+func cliTestDump() {
+	cliTestRecorderInstance.record("dump")
+}
+
+func cliTestStatus() {
+	cliTestRecorderInstance.record("status")
+}
+
+func newCLIForTest(t *testing.T) (fcli.CLI, *[]string) {
+	cli := fcli.NewCLI("tool")
+	usages := []string{}
+	cli.OnError(func(error) int { return fcli.Cusage | fcli.Cerror })
+
+	opt := fcli.WithErrorHandling(flag.ContinueOnError)
+	assert.Nil(t, cli.Add(cliTestStatus, opt))
+	db := cli.Group("db")
+	assert.Nil(t, db.Add(cliTestMigrate, fcli.WithCommandName("migrate"), opt))
+	assert.Nil(t, db.Add(cliTestDump, fcli.WithCommandName("dump"), opt))
+
+	other := fcli.NewCLI("other")
+	assert.Nil(t, other.Add(cliTestDump, fcli.WithCommandName("dump"), opt))
+	assert.Nil(t, db.Mount("ext", other))
+
+	cli.Usage(func() { usages = append(usages, "tool") })
+	db.Usage(func() { usages = append(usages, "db") })
+	other.Usage(func() { usages = append(usages, "ext") })
+	return cli, &usages
+}
+
+func TestCLIGroup(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		args   []string
+		want   []string
+		usages []string
+		err    error
+		errMsg string
+	}{
+		{
+			name:   "no args",
+			args:   []string{},
+			usages: []string{"tool"},
+			err:    fcli.ErrCLINotEnoughArguments,
+		},
+		{
+			name: "top level",
+			args: []string{"cliTestStatus"},
+			want: []string{"status"},
+		},
+		{
+			name: "group",
+			args: []string{"db", "migrate", "-version", "2"},
+			want: []string{"migrate"},
+		},
+		{
+			name: "mounted",
+			args: []string{"db", "ext", "dump"},
+			want: []string{"dump"},
+		},
+		{
+			name:   "group without command",
+			args:   []string{"db"},
+			usages: []string{"db"},
+			err:    fcli.ErrCLINotEnoughArguments,
+			errMsg: "not enough arguments tool db",
+		},
+		{
+			name:   "group command not found",
+			args:   []string{"db", "ext", "load"},
+			usages: []string{"ext"},
+			err:    fcli.ErrCLICommandNotFound,
+			errMsg: "command not found tool db ext load",
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			defer cliTestRecorderInstance.reset()
+			cli, usages := newCLIForTest(t)
+			err := cli.Start(tc.args...)
+			assert.ErrorIs(t, err, tc.err)
+			if tc.errMsg != "" {
+				assert.EqualError(t, err, tc.errMsg)
+			}
+			assert.Equal(t, tc.want, cliTestRecorderInstance.calls)
+			if tc.usages == nil {
+				tc.usages = []string{}
+			}
+			assert.Equal(t, tc.usages, *usages)
+		})
+	}
+}
+
+func TestCLIMount(t *testing.T) {
+	root := fcli.NewCLI("root")
+	child := root.Group("child")
+	assert.ErrorIs(t, child.Mount("root", root), fcli.ErrCLICannotMount, "cycle")
+	assert.ErrorIs(t, root.Mount("again", child), fcli.ErrCLICannotMount, "mounted twice")
+	assert.Nil(t, root.Mount("other", fcli.NewCLI("other")))
+}
+
+func cliTestGreet() {
+	cliTestRecorderInstance.record("greet")
+}
+
+func cliTestGrep() {
+	cliTestRecorderInstance.record("grep")
+}
+
+func TestCLIAlias(t *testing.T) {
+	newCLI := func(t *testing.T, prefixMatch bool) fcli.CLI {
+		cli := fcli.NewCLI("do")
+		cli.OnError(func(error) int { return fcli.Cerror })
+		cli.PrefixMatch(prefixMatch)
+		assert.Nil(t, cli.Add(cliTestGreet, fcli.WithCommandName("greet"), fcli.WithAliases([]string{"hello", "hi"})))
+		assert.Nil(t, cli.Add(cliTestGrep, fcli.WithCommandName("grep")))
+		db := cli.Group("db")
+		assert.Nil(t, db.Add(cliTestDump, fcli.WithCommandName("dump")))
+		return cli
+	}
+
+	for _, tc := range []struct {
+		name        string
+		prefixMatch bool
+		args        []string
+		want        []string
+		err         error
+		candidates  []string
+	}{
+		{
+			name: "name",
+			args: []string{"greet"},
+			want: []string{"greet"},
+		},
+		{
+			name: "alias",
+			args: []string{"hi"},
+			want: []string{"greet"},
+		},
+		{
+			name: "prefix disabled",
+			args: []string{"gree"},
+			err:  fcli.ErrCLICommandNotFound,
+		},
+		{
+			name:        "prefix",
+			prefixMatch: true,
+			args:        []string{"gree"},
+			want:        []string{"greet"},
+		},
+		{
+			name:        "prefix of alias",
+			prefixMatch: true,
+			args:        []string{"hel"},
+			want:        []string{"greet"},
+		},
+		{
+			name:        "prefix matches name and alias of the same command",
+			prefixMatch: true,
+			args:        []string{"h"},
+			want:        []string{"greet"},
+		},
+		{
+			name:        "prefix in group",
+			prefixMatch: true,
+			args:        []string{"d", "du"},
+			want:        []string{"dump"},
+		},
+		{
+			name:        "ambiguous",
+			prefixMatch: true,
+			args:        []string{"gr"},
+			err:         fcli.ErrCLIAmbiguousCommand,
+			candidates:  []string{"greet", "grep"},
+		},
+		{
+			name:        "prefix not found",
+			prefixMatch: true,
+			args:        []string{"x"},
+			err:         fcli.ErrCLICommandNotFound,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			defer cliTestRecorderInstance.reset()
+			err := newCLI(t, tc.prefixMatch).Start(tc.args...)
+			assert.ErrorIs(t, err, tc.err)
+			assert.Equal(t, tc.want, cliTestRecorderInstance.calls)
+			if tc.candidates != nil {
+				var e *fcli.AmbiguousCommandError
+				if assert.ErrorAs(t, err, &e) {
+					assert.Equal(t, tc.candidates, e.Candidates)
+				}
+			}
+		})
+	}
+}
+
+func TestCLISuggestion(t *testing.T) {
+	cli := fcli.NewCLI("do")
+	cli.OnError(func(error) int { return fcli.Cerror })
+	assert.Nil(t, cli.Add(cliTestGreet, fcli.WithCommandName("greet")))
+	assert.Nil(t, cli.Add(cliTestGrep, fcli.WithCommandName("grep")))
+	assert.Nil(t, cli.Add(cliTestDump, fcli.WithCommandName("dump")))
+
+	for _, tc := range []struct {
+		name string
+		arg  string
+		want []string
+	}{
+		{
+			name: "typo",
+			arg:  "gret",
+			want: []string{"greet", "grep"},
+		},
+		{
+			name: "transposition",
+			arg:  "dupm",
+			want: []string{"dump"},
+		},
+		{
+			name: "far",
+			arg:  "xyz",
+			want: []string{},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := cli.Start(tc.arg)
+			var e *fcli.CommandNotFoundError
+			if !assert.ErrorAs(t, err, &e) {
+				return
+			}
+			assert.ErrorIs(t, err, fcli.ErrCLICommandNotFound)
+			assert.Equal(t, tc.arg, e.Name)
+			assert.Equal(t, tc.want, e.Suggestions)
+		})
+	}
+}
+
+type cliTestGlobalResult struct {
+	verbose bool
+	config  string
+	ctxOK   bool
+}
+
+var cliTestGlobalResultInstance cliTestGlobalResult
+
+func cliTestGlobal(ctx context.Context, verbose bool) {
+	config, ok := fcli.GlobalFlag(ctx, "config")
+	cliTestGlobalResultInstance.verbose = verbose
+	cliTestGlobalResultInstance.ctxOK = ok
+	if ok {
+		cliTestGlobalResultInstance.config = config.(string)
+	}
+}
+
+func TestCLIGlobalFlag(t *testing.T) {
+	newCLI := func(t *testing.T) fcli.CLI {
+		cli := fcli.NewCLI("tool")
+		cli.OnError(func(error) int { return fcli.Cerror })
+		assert.Nil(t, cli.AddGlobalFlag("verbose", false, ""))
+		assert.Nil(t, cli.AddGlobalFlag("config", "", ""))
+		assert.ErrorIs(t, cli.AddGlobalFlag("config", "", ""), fcli.ErrCLIInvalidGlobalFlag)
+		assert.ErrorIs(t, cli.AddGlobalFlag("ptr", uintptr(0), ""), fcli.ErrCLIInvalidGlobalFlag)
+		opt := fcli.WithErrorHandling(flag.ContinueOnError)
+		assert.Nil(t, cli.Add(cliTestGlobal, fcli.WithCommandName("run"), opt))
+		assert.Nil(t, cli.Group("sub").Add(cliTestGlobal, fcli.WithCommandName("run"), opt))
+		return cli
+	}
+
+	for _, tc := range []struct {
+		name string
+		args []string
+		want cliTestGlobalResult
+		err  error
+	}{
+		{
+			name: "no global flags",
+			args: []string{"run"},
+			want: cliTestGlobalResult{
+				ctxOK: true,
+			},
+		},
+		{
+			name: "global flags",
+			args: []string{"-verbose", "-config", "c.yml", "run"},
+			want: cliTestGlobalResult{
+				verbose: true,
+				config:  "c.yml",
+				ctxOK:   true,
+			},
+		},
+		{
+			name: "command flag has priority",
+			args: []string{"-verbose", "run", "-verbose=false"},
+			want: cliTestGlobalResult{
+				ctxOK: true,
+			},
+		},
+		{
+			name: "in group",
+			args: []string{"-verbose", "sub", "run"},
+			want: cliTestGlobalResult{
+				verbose: true,
+				ctxOK:   true,
+			},
+		},
+		{
+			name: "unknown global flag",
+			args: []string{"-unknown", "run"},
+			err:  fcli.ErrCLIInvalidGlobalFlag,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			defer func() {
+				cliTestGlobalResultInstance = cliTestGlobalResult{}
+			}()
+			err := newCLI(t).Start(tc.args...)
+			assert.ErrorIs(t, err, tc.err)
+			assert.Equal(t, tc.want, cliTestGlobalResultInstance)
+		})
+	}
+}
+
+func cliTestGlobalDefault(ctx context.Context) {
+	config, _ := fcli.GlobalFlag(ctx, "config")
+	workers, _ := fcli.GlobalFlag(ctx, "workers")
+	verbose, _ := fcli.GlobalFlag(ctx, "verbose")
+	cliTestRecorderInstance.record(fmt.Sprintf("%v %v %v", config, workers, verbose))
+}
+
+func TestCLIGlobalFlagDefault(t *testing.T) {
+	defer cliTestRecorderInstance.reset()
+	cli := fcli.NewCLI("tool")
+	cli.OnError(func(error) int { return fcli.Cerror })
+	assert.Nil(t, cli.AddGlobalFlag("config", "default.yml", "config file"))
+	assert.Nil(t, cli.AddGlobalFlag("workers", 4, "number of workers"))
+	assert.Nil(t, cli.AddGlobalFlag("verbose", false, "verbose output"))
+	assert.Nil(t, cli.Add(cliTestGlobalDefault, fcli.WithCommandName("run")))
+
+	assert.Nil(t, cli.Start("run"))
+	assert.Nil(t, cli.Start("-workers", "2", "-config", "", "run"))
+	assert.Equal(t, []string{
+		"default.yml 4 false",
+		" 2 false",
+	}, cliTestRecorderInstance.calls)
+
+	usage := captureStdout(t, func() {
+		assert.Nil(t, cli.Start("help"))
+	})
+	assert.Contains(t, usage, `Flags:
+  -config string
+    	config file (default "default.yml")
+  -verbose
+    	verbose output
+  -workers int
+    	number of workers (default 4)
+`)
+}
+
+func cliTestDefaultGlobal(ctx context.Context, name string) {
+	config, _ := fcli.GlobalFlag(ctx, "config")
+	verbose, _ := fcli.GlobalFlag(ctx, "verbose")
+	cliTestRecorderInstance.record(fmt.Sprintf("%v %v %s", config, verbose, name))
+}
+
+func TestCLIDefaultWithGlobalFlags(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		args []string
+		want []string
+		err  error
+	}{
+		{
+			name: "flags of default",
+			args: []string{"-name", "world"},
+			want: []string{" false world"},
+		},
+		{
+			name: "global flags and flags of default",
+			args: []string{"-config", "c.yml", "-verbose", "-name", "world"},
+			want: []string{"c.yml true world"},
+		},
+		{
+			name: "with equals",
+			args: []string{"-config=c.yml", "--name=world"},
+			want: []string{"c.yml false world"},
+		},
+		{
+			name: "global flags only",
+			args: []string{"-config", "c.yml"},
+			want: []string{"c.yml false "},
+		},
+		{
+			name: "command given",
+			args: []string{"-verbose", "run", "-name", "x"},
+			want: []string{" true x"},
+		},
+		{
+			name: "help",
+			args: []string{"-config", "c.yml", "-h"},
+			err:  flag.ErrHelp,
+		},
+		{
+			name: "unknown flag of default",
+			args: []string{"-config", "c.yml", "-unknown"},
+			err:  fcli.ErrParseFailure,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			defer cliTestRecorderInstance.reset()
+			cli := fcli.NewCLI("tool")
+			cli.OnError(func(error) int { return fcli.Cerror })
+			cli.Usage(fcli.NilUsage)
+			assert.Nil(t, cli.AddGlobalFlag("config", "", ""))
+			assert.Nil(t, cli.AddGlobalFlag("verbose", false, ""))
+			assert.Nil(t, cli.Add(cliTestDefaultGlobal,
+				fcli.WithCommandName("run"),
+				fcli.WithErrorHandling(flag.ContinueOnError),
+			))
+			cli.Default("run")
+			err := cli.Start(tc.args...)
+			assert.ErrorIs(t, err, tc.err)
+			assert.Equal(t, tc.want, cliTestRecorderInstance.calls)
+		})
+	}
+}
+
+func TestCLIDefaultAndFallback(t *testing.T) {
+	newCLI := func(t *testing.T, defaultCommand string, fallback bool) fcli.CLI {
+		cli := fcli.NewCLI("tool")
+		cli.OnError(func(error) int { return fcli.Cerror })
+		opt := fcli.WithErrorHandling(flag.ContinueOnError)
+		assert.Nil(t, cli.Add(cliTestMigrate, fcli.WithCommandName("migrate"), opt))
+		assert.Nil(t, cli.Add(cliTestDump, fcli.WithCommandName("dump"), opt))
+		cli.Default(defaultCommand)
+		if fallback {
+			cli.Fallback(func(_ context.Context, arguments []string) error {
+				cliTestRecorderInstance.record("fallback " + strings.Join(arguments, " "))
+				return nil
+			})
+		}
+		return cli
+	}
+
+	for _, tc := range []struct {
+		name           string
+		defaultCommand string
+		fallback       bool
+		args           []string
+		want           []string
+		err            error
+	}{
+		{
+			name: "no default",
+			args: []string{},
+			err:  fcli.ErrCLINotEnoughArguments,
+		},
+		{
+			name:           "default",
+			defaultCommand: "dump",
+			args:           []string{},
+			want:           []string{"dump"},
+		},
+		{
+			name:           "default with flags",
+			defaultCommand: "migrate",
+			args:           []string{"-version", "1"},
+			want:           []string{"migrate"},
+		},
+		{
+			name:           "default not found",
+			defaultCommand: "load",
+			args:           []string{},
+			err:            fcli.ErrCLICommandNotFound,
+		},
+		{
+			name:           "command given",
+			defaultCommand: "migrate",
+			args:           []string{"dump"},
+			want:           []string{"dump"},
+		},
+		{
+			name:     "fallback",
+			fallback: true,
+			args:     []string{"script.sh", "-x"},
+			want:     []string{"fallback script.sh -x"},
+		},
+		{
+			name: "no fallback",
+			args: []string{"script.sh"},
+			err:  fcli.ErrCLICommandNotFound,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			defer cliTestRecorderInstance.reset()
+			err := newCLI(t, tc.defaultCommand, tc.fallback).Start(tc.args...)
+			assert.ErrorIs(t, err, tc.err)
+			assert.Equal(t, tc.want, cliTestRecorderInstance.calls)
+		})
+	}
+}
+
+func TestCLIDefaultNotFound(t *testing.T) {
+	cli := fcli.NewCLI("tool")
+	cli.OnError(func(error) int { return fcli.Cerror })
+	assert.Nil(t, cli.Add(cliTestDump, fcli.WithCommandName("dump")))
+	cli.Default("load")
+
+	err := cli.Start()
+	assert.ErrorIs(t, err, fcli.ErrCLICommandNotFound)
+	assert.Contains(t, err.Error(), "tool default command load")
+	var notFound *fcli.CommandNotFoundError
+	assert.False(t, errors.As(err, &notFound), "no suggestions for the name not given")
+	assert.ErrorIs(t, cli.Validate(), fcli.ErrCLIInvalid)
+}
+
+func captureStdout(t *testing.T, f func()) string {
+	return captureFile(t, &os.Stdout, f)
+}
+
+func captureStderr(t *testing.T, f func()) string {
+	return captureFile(t, &os.Stderr, f)
+}
+
+// captureFile returns the output written to *file while calling f.
+func captureFile(t *testing.T, file **os.File, f func()) string {
+	r, w, err := os.Pipe()
+	if !assert.Nil(t, err) {
+		return ""
+	}
+	original := *file
+	*file = w
+	defer func() {
+		*file = original
+	}()
+
+	done := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(r)
+		done <- string(b)
+	}()
+	f()
+	w.Close()
+	return <-done
+}
+
+// cliTestHelpGreet greets you.
+// Second line.
+func cliTestHelpGreet(name string) {}
+
+func TestCLIHelp(t *testing.T) {
+	cli := fcli.NewCLI("tool")
+	cli.OnError(func(error) int { return fcli.Cerror })
+	assert.Nil(t, cli.AddGlobalFlag("v", false, ""))
+	assert.Nil(t, cli.Add(cliTestHelpGreet, fcli.WithCommandName("greet")))
+	assert.Nil(t, cli.Add(func(n int) {},
+		fcli.WithCommandName("lit"),
+		fcli.WithParamNames([]string{"n"}),
+		fcli.WithDoc("lit has the doc."),
+	))
+	assert.Nil(t, cli.Group("db").Add(cliTestDump, fcli.WithCommandName("dump")))
+	cli.AddHelpTopic("config", "Config is read from ~/.tool.yml")
+
+	for _, tc := range []struct {
+		name string
+		args []string
+		want string
+		err  error
+	}{
+		{
+			name: "usage",
+			args: []string{"help"},
+			want: `Usage: tool [flags] <command> [arguments]
+
+Commands:
+  db     (group)
+  greet  cliTestHelpGreet greets you.
+  lit    lit has the doc.
+
+Flags:
+  -v	
+
+Help topics:
+  config
+
+Run 'tool help <command>' for more information.
+`,
+		},
+		{
+			name: "command",
+			args: []string{"help", "greet"},
+			want: `cliTestHelpGreet greets you.
+Second line.
+Usage of greet:
+  -name string
+    	
+`,
+		},
+		{
+			name: "command with doc option",
+			args: []string{"help", "lit"},
+			want: `lit has the doc.
+Usage of lit:
+  -n int
+    	
+`,
+		},
+		{
+			name: "group",
+			args: []string{"help", "db"},
+			want: `Usage: tool db <command> [arguments]
+
+Commands:
+  dump
+
+Run 'tool db help <command>' for more information.
+`,
+		},
+		{
+			name: "command in group",
+			args: []string{"db", "help", "dump"},
+			want: "Usage of dump:\n",
+		},
+		{
+			name: "topic",
+			args: []string{"help", "config"},
+			want: "Config is read from ~/.tool.yml\n",
+		},
+		{
+			name: "not found",
+			args: []string{"help", "gret"},
+			err:  fcli.ErrCLICommandNotFound,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var err error
+			got := captureStdout(t, func() {
+				err = cli.Start(tc.args...)
+			})
+			assert.ErrorIs(t, err, tc.err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestCLIHelpTopicClash(t *testing.T) {
+	cli := fcli.NewCLI("tool")
+	cli.OnError(func(error) int { return fcli.Cerror })
+	assert.Nil(t, cli.Add(cliTestHelpGreet, fcli.WithCommandName("greet"), fcli.WithAliases([]string{"g"})))
+	cli.AddHelpTopic("greet", "Greet topic")
+	cli.AddHelpTopic("g", "G topic")
+
+	for _, args := range [][]string{{"help", "greet"}, {"help", "g"}} {
+		var err error
+		got := captureStdout(t, func() {
+			err = cli.Start(args...)
+		})
+		assert.Nil(t, err)
+		assert.True(t, strings.HasPrefix(got, "cliTestHelpGreet greets you.\n"), "%v printed %q", args, got)
+	}
+	assert.ErrorIs(t, cli.Validate(), fcli.ErrCLIInvalid)
+}
+
+// cliTestUsageZap removes everything. Use it with care.
+func cliTestUsageZap() {}
+
+// cliTestUsageAdd adds
+// a row! It is not undoable.
+func cliTestUsageAdd() {}
+
+// cliTestUsageList lists rows
+//
+// Second paragraph.
+func cliTestUsageList() {}
+
+func TestCLIUsage(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		order         fcli.CommandOrder
+		category      bool
+		categoryOrder []string
+		want          string
+	}{
+		{
+			name: "alphabetical",
+			want: `Usage: tool <command> [arguments]
+
+Commands:
+  add   cliTestUsageAdd adds a row!
+  list  cliTestUsageList lists rows
+  zap   cliTestUsageZap removes everything.
+
+Run 'tool help <command>' for more information.
+`,
+		},
+		{
+			name:  "registration",
+			order: fcli.OrderRegistration,
+			want: `Usage: tool <command> [arguments]
+
+Commands:
+  zap   cliTestUsageZap removes everything.
+  add   cliTestUsageAdd adds a row!
+  list  cliTestUsageList lists rows
+
+Run 'tool help <command>' for more information.
+`,
+		},
+		{
+			name:     "category",
+			category: true,
+			want: `Usage: tool <command> [arguments]
+
+Danger:
+  zap  cliTestUsageZap removes everything.
+
+Write:
+  add  cliTestUsageAdd adds a row!
+
+Other:
+  list  cliTestUsageList lists rows
+
+Run 'tool help <command>' for more information.
+`,
+		},
+		{
+			name:          "category order",
+			category:      true,
+			categoryOrder: []string{"Write", fcli.CategoryOther},
+			want: `Usage: tool <command> [arguments]
+
+Write:
+  add  cliTestUsageAdd adds a row!
+
+Other:
+  list  cliTestUsageList lists rows
+
+Danger:
+  zap  cliTestUsageZap removes everything.
+
+Run 'tool help <command>' for more information.
+`,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			category := func(v string) fcli.Option {
+				if tc.category {
+					return fcli.WithCategory(v)
+				}
+				return fcli.WithCategory("")
+			}
+			cli := fcli.NewCLI("tool")
+			cli.Order(tc.order)
+			cli.CategoryOrder(tc.categoryOrder...)
+			assert.Nil(t, cli.Add(cliTestUsageZap, fcli.WithCommandName("zap"), category("Danger")))
+			assert.Nil(t, cli.Add(cliTestUsageAdd, fcli.WithCommandName("add"), category("Write")))
+			assert.Nil(t, cli.Add(cliTestUsageList, fcli.WithCommandName("list")))
+			got := captureStdout(t, func() {
+				assert.Nil(t, cli.Start("help"))
+			})
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func cliTestIntercepted(ctx context.Context, name string) error {
+	cliTestRecorderInstance.record("func " + name)
+	if name == "panic" {
+		panic("panic")
+	}
+	return nil
+}
+
+func TestCLIInterceptor(t *testing.T) {
+	var errDenied = errors.New("denied")
+	record := func(label string) fcli.Interceptor {
+		return func(ctx context.Context, inv *fcli.Invocation, next func(context.Context) error) error {
+			cliTestRecorderInstance.record(fmt.Sprintf("%s before %s %v", label, inv.Name, inv.Values["name"]))
+			err := next(ctx)
+			cliTestRecorderInstance.record(fmt.Sprintf("%s after %v", label, err != nil))
+			return err
+		}
+	}
+	deny := func(ctx context.Context, inv *fcli.Invocation, next func(context.Context) error) error {
+		if inv.Values["name"] == "root" {
+			return errDenied
+		}
+		return next(ctx)
+	}
+
+	cli := fcli.NewCLI("tool")
+	cli.OnError(func(error) int { return fcli.Cerror })
+	cli.Use(record("root"), deny)
+	g := cli.Group("g")
+	g.Use(record("group"))
+	assert.Nil(t, g.Add(cliTestIntercepted,
+		fcli.WithCommandName("run"),
+		fcli.WithErrorHandling(flag.ContinueOnError),
+		fcli.WithInterceptors([]fcli.Interceptor{record("command")}),
+	))
+
+	for _, tc := range []struct {
+		name string
+		arg  string
+		want []string
+		err  error
+	}{
+		{
+			name: "chain",
+			arg:  "alice",
+			want: []string{
+				"root before run alice",
+				"group before run alice",
+				"command before run alice",
+				"func alice",
+				"command after false",
+				"group after false",
+				"root after false",
+			},
+		},
+		{
+			name: "short-circuit",
+			arg:  "root",
+			want: []string{
+				"root before run root",
+				"root after true",
+			},
+			err: errDenied,
+		},
+		{
+			name: "panic",
+			arg:  "panic",
+			want: []string{
+				"root before run panic",
+				"group before run panic",
+				"command before run panic",
+				"func panic",
+			},
+			err: fcli.ErrCallFailure,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			defer cliTestRecorderInstance.reset()
+			err := cli.Start("g", "run", "-name", tc.arg)
+			assert.ErrorIs(t, err, tc.err)
+			assert.Equal(t, tc.want, cliTestRecorderInstance.calls)
+		})
+	}
+}
+
+func cliTestConcurrent(name string, n int) error {
+	if name != fmt.Sprint(n) {
+		return fmt.Errorf("name %q n %d", name, n)
+	}
+	return nil
+}
+
+var cliTestConcurrentCLI fcli.CLI
+
+// cliTestConcurrentUse modifies the CLI while called by it.
+func cliTestConcurrentUse() {
+	cliTestConcurrentCLI.Use(func(ctx context.Context, _ *fcli.Invocation, next func(context.Context) error) error {
+		return next(ctx)
+	})
+}
+
+func TestCLIConcurrent(t *testing.T) {
+	cli := fcli.NewCLI("tool")
+	cliTestConcurrentCLI = cli
+	cli.OnError(func(error) int { return fcli.Cerror })
+	assert.Nil(t, cli.AddGlobalFlag("v", false, ""))
+	assert.Nil(t, cli.Add(cliTestConcurrent, fcli.WithCommandName("check"), fcli.WithErrorHandling(flag.ContinueOnError)))
+	assert.Nil(t, cli.Add(cliTestConcurrentUse, fcli.WithCommandName("use")))
+
+	var (
+		wg   sync.WaitGroup
+		errC = make(chan error, 200)
+	)
+	for i := 0; i < 100; i++ {
+		i := i
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			errC <- cli.Start("-v", "check", "-name", fmt.Sprint(i), "-n", fmt.Sprint(i))
+		}()
+		go func() {
+			defer wg.Done()
+			switch i % 4 {
+			case 0:
+				errC <- cli.Group(fmt.Sprintf("g%d", i)).Add(cliTestDump, fcli.WithCommandName("dump"))
+			case 1:
+				cli.PrefixMatch(i%8 == 1)
+				errC <- cli.Start("use")
+			case 2:
+				errC <- cli.Add(cliTestStatus, fcli.WithCommandName(fmt.Sprintf("status%d", i)))
+			default:
+				errC <- cli.WriteCompletion(io.Discard, "bash")
+			}
+		}()
+	}
+	wg.Wait()
+	close(errC)
+	for err := range errC {
+		assert.Nil(t, err)
+	}
+}
+
+func cliTestNop() {}
+
+func TestCLIInterceptorConcurrent(t *testing.T) {
+	var mismatches int32
+	nop := func(ctx context.Context, _ *fcli.Invocation, next func(context.Context) error) error {
+		return next(ctx)
+	}
+	expect := func(name string) fcli.Interceptor {
+		return func(ctx context.Context, inv *fcli.Invocation, next func(context.Context) error) error {
+			if inv.Name != name {
+				atomic.AddInt32(&mismatches, 1)
+			}
+			return next(ctx)
+		}
+	}
+
+	cli := fcli.NewCLI("tool")
+	cli.OnError(func(error) int { return fcli.Cerror })
+	// the chain of the root has spare capacity
+	cli.Use(nop)
+	cli.Use(nop)
+	cli.Use(nop)
+	for _, name := range []string{"a", "b"} {
+		g := cli.Group(name)
+		g.Use(expect(name))
+		assert.Nil(t, g.Add(cliTestNop, fcli.WithCommandName(name)))
+	}
+
+	var (
+		wg   sync.WaitGroup
+		errC = make(chan error, 200)
+	)
+	for i := 0; i < 100; i++ {
+		name := []string{"a", "b"}[i%2]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errC <- cli.Start(name, name)
+		}()
+	}
+	wg.Wait()
+	close(errC)
+	for err := range errC {
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, int32(0), atomic.LoadInt32(&mismatches))
+}
+
+func cliTestReplaced() {
+	cliTestRecorderInstance.record("replaced")
+}
+
+func TestCLIAddDuplicate(t *testing.T) {
+	defer cliTestRecorderInstance.reset()
+	cli := fcli.NewCLI("tool")
+	cli.OnError(func(error) int { return fcli.Cerror })
+	assert.Nil(t, cli.Add(cliTestStatus, fcli.WithCommandName("status"), fcli.WithAliases([]string{"st"})))
+	_ = cli.Group("db")
+
+	assert.ErrorIs(t, cli.Add(cliTestReplaced, fcli.WithCommandName("status")), fcli.ErrCLIDuplicateCommand)
+	assert.ErrorIs(t, cli.Add(cliTestReplaced, fcli.WithCommandName("db")), fcli.ErrCLIDuplicateCommand)
+	assert.ErrorIs(t, cli.Add(cliTestReplaced, fcli.WithCommandName("db"), fcli.WithReplace(true)), fcli.ErrCLIDuplicateCommand)
+	assert.ErrorIs(t, cli.Mount("status", fcli.NewCLI("other")), fcli.ErrCLICannotMount)
+	assert.ErrorIs(t, cli.Mount("db", fcli.NewCLI("other")), fcli.ErrCLICannotMount)
+	// the group does not shadow the command
+	_, err := cli.AddGroup("status")
+	assert.ErrorIs(t, err, fcli.ErrCLIDuplicateCommand)
+	assert.Nil(t, cli.Group("status").Add(cliTestReplaced, fcli.WithCommandName("sub")))
+	assert.Nil(t, cli.Start("status"))
+	assert.Equal(t, []string{"status"}, cliTestRecorderInstance.calls)
+	cliTestRecorderInstance.reset()
+	db, err := cli.AddGroup("db")
+	assert.Nil(t, err)
+	assert.Equal(t, cli.Group("db"), db)
+
+	assert.Nil(t, cli.Add(cliTestReplaced, fcli.WithCommandName("status"), fcli.WithReplace(true)))
+	assert.Nil(t, cli.Start("status"))
+	assert.Equal(t, []string{"replaced"}, cliTestRecorderInstance.calls)
+	// the aliases of the replaced command are removed
+	assert.ErrorIs(t, cli.Start("st"), fcli.ErrCLICommandNotFound)
+}
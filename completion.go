@@ -0,0 +1,157 @@
+package fcli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+var (
+	ErrCLIUnsupportedShell = errors.New("unsupported shell")
+)
+
+// completeCommandName is the hidden command called by the completion scripts.
+// `tool __complete WORD... CURRENT` prints the candidates for CURRENT.
+const completeCommandName = "__complete"
+
+// complete returns the candidates for the last element of args.
+func (s *cliMap) complete(args []string) []string {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	var (
+		current = args[len(args)-1]
+		words   = args[:len(args)-1]
+		level   = s
+		cmd     *targetFunction
+		help    bool
+	)
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		if cmd != nil {
+			break
+		}
+		if strings.HasPrefix(w, "-") {
+			// skip the value of the global flag
+			if name := strings.TrimLeft(w, "-"); !strings.Contains(name, "=") && level.globalFlagTakesValue(name) {
+				i++
+			}
+			continue
+		}
+		if w == helpCommandName {
+			help = true
+			continue
+		}
+		name, err := level.resolve(w)
+		if err != nil {
+			return nil
+		}
+		if g, ok := level.groups[name]; ok {
+			level = g
+			continue
+		}
+		cmd = level.commands[name]
+	}
+
+	var candidates []string
+	switch {
+	case strings.HasPrefix(current, "-") && cmd != nil:
+		for _, f := range cmd.flags {
+			candidates = append(candidates, "-"+f.Name())
+		}
+	case strings.HasPrefix(current, "-"):
+		for _, g := range level.globals {
+			candidates = append(candidates, "-"+g.name)
+		}
+	case cmd != nil:
+		return nil
+	default:
+		candidates = append(candidates, level.visibleNames()...)
+		if help {
+			for k := range level.topics {
+				candidates = append(candidates, k)
+			}
+		} else {
+			candidates = append(candidates, helpCommandName)
+			candidates = append(candidates, level.pluginNames()...)
+		}
+	}
+
+	r := []string{}
+	for _, x := range candidates {
+		if strings.HasPrefix(x, current) {
+			r = append(r, x)
+		}
+	}
+	sort.Strings(r)
+	return r
+}
+
+func (s *cliMap) globalFlagTakesValue(name string) bool {
+	flagSet, _ := s.newGlobalFlagSet()
+	f := flagSet.Lookup(name)
+	if f == nil {
+		return false
+	}
+	if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+		return false
+	}
+	return true
+}
+
+func (s *cliMap) writeCompletions(w io.Writer, args []string) {
+	cliMu.RLock()
+	defer cliMu.RUnlock()
+	for _, x := range s.complete(args) {
+		fmt.Fprintln(w, x)
+	}
+}
+
+var completionTemplates = map[string]*template.Template{
+	"bash": template.Must(template.New("bash").Parse(`# bash completion for {{.Name}}
+{{.Func}}() {
+    local IFS=$'\n'
+    COMPREPLY=($("${COMP_WORDS[0]}" {{.Complete}} "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F {{.Func}} {{.Name}}
+`)),
+	"zsh": template.Must(template.New("zsh").Parse(`#compdef {{.Name}}
+# zsh completion for {{.Name}}
+{{.Func}}() {
+    local -a candidates
+    candidates=(${(f)"$("${words[1]}" {{.Complete}} "${(@)words[2,CURRENT]}" 2>/dev/null)"})
+    compadd -a candidates
+}
+compdef {{.Func}} {{.Name}}
+`)),
+	"fish": template.Must(template.New("fish").Parse(`# fish completion for {{.Name}}
+function {{.Func}}
+    set -l tokens (commandline -opc)
+    set -l cmd $tokens[1]
+    set -e tokens[1]
+    $cmd {{.Complete}} $tokens (commandline -ct) 2>/dev/null
+end
+complete -c {{.Name}} -f -a '({{.Func}})'
+`)),
+}
+
+var notIdentifierRegexp = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+func (s *cliMap) WriteCompletion(w io.Writer, shell string) error {
+	t, ok := completionTemplates[shell]
+	if !ok {
+		return fmt.Errorf("%w %s", ErrCLIUnsupportedShell, shell)
+	}
+	cliMu.RLock()
+	name := s.root().name
+	cliMu.RUnlock()
+	return t.Execute(w, map[string]string{
+		"Name":     name,
+		"Func":     "_" + notIdentifierRegexp.ReplaceAllString(name, "_") + "_complete",
+		"Complete": completeCommandName,
+	})
+}
@@ -0,0 +1,102 @@
+package fcli_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/berquerant/fcli"
+	"github.com/stretchr/testify/assert"
+)
+
+func completionTestGreet(name string, count int) {}
+
+func TestCLIComplete(t *testing.T) {
+	cli := fcli.NewCLI("tool")
+	assert.Nil(t, cli.AddGlobalFlag("config", "", ""))
+	assert.Nil(t, cli.AddGlobalFlag("verbose", false, ""))
+	assert.Nil(t, cli.Add(completionTestGreet, fcli.WithCommandName("greet")))
+	assert.Nil(t, cli.Add(completionTestGreet, fcli.WithCommandName("grep")))
+	db := cli.Group("db")
+	assert.Nil(t, db.Add(completionTestGreet, fcli.WithCommandName("dump")))
+	cli.AddHelpTopic("config", "")
+
+	for _, tc := range []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			name: "empty",
+			args: []string{},
+			want: []string{"db", "greet", "grep", "help"},
+		},
+		{
+			name: "command prefix",
+			args: []string{"gr"},
+			want: []string{"greet", "grep"},
+		},
+		{
+			name: "group",
+			args: []string{"db", ""},
+			want: []string{"dump", "help"},
+		},
+		{
+			name: "command flags",
+			args: []string{"greet", "-"},
+			want: []string{"-count", "-name"},
+		},
+		{
+			name: "command flag prefix",
+			args: []string{"greet", "-name", "x", "-c"},
+			want: []string{"-count"},
+		},
+		{
+			name: "command flag value",
+			args: []string{"greet", "-name", ""},
+			want: []string{},
+		},
+		{
+			name: "global flags",
+			args: []string{"-"},
+			want: []string{"-config", "-verbose"},
+		},
+		{
+			name: "after global flags",
+			args: []string{"-config", "c.yml", "-verbose", "d"},
+			want: []string{"db"},
+		},
+		{
+			name: "help topics",
+			args: []string{"help", "c"},
+			want: []string{"config"},
+		},
+		{
+			name: "unknown",
+			args: []string{"unknown", ""},
+			want: []string{},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got := captureStdout(t, func() {
+				assert.Nil(t, cli.Start(append([]string{"__complete"}, tc.args...)...))
+			})
+			assert.Equal(t, tc.want, strings.Fields(got))
+		})
+	}
+}
+
+func TestCLIWriteCompletion(t *testing.T) {
+	cli := fcli.NewCLI("my-tool")
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		shell := shell
+		t.Run(shell, func(t *testing.T) {
+			var b bytes.Buffer
+			assert.Nil(t, cli.WriteCompletion(&b, shell))
+			assert.Contains(t, b.String(), "_my_tool_complete")
+			assert.Contains(t, b.String(), "__complete")
+		})
+	}
+	assert.ErrorIs(t, cli.WriteCompletion(&bytes.Buffer{}, "csh"), fcli.ErrCLIUnsupportedShell)
+}
@@ -4,7 +4,7 @@ import (
 	"github.com/berquerant/fcli/internal/logger"
 )
 
-//go:generate go run github.com/berquerant/goconfig@latest -type "flag.ErrorHandling,CommandName|string" -option -output config_generated.go -configOption Option
+//go:generate go run github.com/berquerant/goconfig@latest -type "flag.ErrorHandling,CommandName|string,Aliases|[]string,Category|string,Interceptors|[]Interceptor,Replace|bool,Hidden|bool,Deprecated|string,DeprecatedFlags|map[string]string,RenamedFlags|map[string]string,ParamNames|[]string,Doc|string,Stdout|io.Writer,Stderr|io.Writer,Stdin|io.Reader,Description|string" -option -output config_generated.go -configOption Option
 
 func SetVerboseLevel(level int) {
 	switch {
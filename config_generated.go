@@ -1,8 +1,11 @@
-// Code generated by "goconfig -type flag.ErrorHandling,CommandName|string -option -output config_generated.go -configOption Option"; DO NOT EDIT.
+// Code generated by "goconfig -type flag.ErrorHandling,CommandName|string,Aliases|[]string,Category|string,Interceptors|[]Interceptor,Replace|bool,Hidden|bool,Deprecated|string,DeprecatedFlags|map[string]string,RenamedFlags|map[string]string,ParamNames|[]string,Doc|string,Stdout|io.Writer,Stderr|io.Writer,Stdin|io.Reader,Description|string -option -output config_generated.go -configOption Option"; DO NOT EDIT.
 
 package fcli
 
-import "flag"
+import (
+	"flag"
+	"io"
+)
 
 type ConfigItem[T any] struct {
 	modified     bool
@@ -33,12 +36,40 @@ func NewConfigItem[T any](defaultValue T) *ConfigItem[T] {
 }
 
 type Config struct {
-	ErrorHandling *ConfigItem[flag.ErrorHandling]
-	CommandName   *ConfigItem[string]
+	ErrorHandling   *ConfigItem[flag.ErrorHandling]
+	CommandName     *ConfigItem[string]
+	Aliases         *ConfigItem[[]string]
+	Category        *ConfigItem[string]
+	Interceptors    *ConfigItem[[]Interceptor]
+	Replace         *ConfigItem[bool]
+	Hidden          *ConfigItem[bool]
+	Deprecated      *ConfigItem[string]
+	DeprecatedFlags *ConfigItem[map[string]string]
+	RenamedFlags    *ConfigItem[map[string]string]
+	ParamNames      *ConfigItem[[]string]
+	Doc             *ConfigItem[string]
+	Stdout          *ConfigItem[io.Writer]
+	Stderr          *ConfigItem[io.Writer]
+	Stdin           *ConfigItem[io.Reader]
+	Description     *ConfigItem[string]
 }
 type ConfigBuilder struct {
-	errorHandling flag.ErrorHandling
-	commandName   string
+	errorHandling   flag.ErrorHandling
+	commandName     string
+	aliases         []string
+	category        string
+	interceptors    []Interceptor
+	replace         bool
+	hidden          bool
+	deprecated      string
+	deprecatedFlags map[string]string
+	renamedFlags    map[string]string
+	paramNames      []string
+	doc             string
+	stdout          io.Writer
+	stderr          io.Writer
+	stdin           io.Reader
+	description     string
 }
 
 func (s *ConfigBuilder) ErrorHandling(v flag.ErrorHandling) *ConfigBuilder {
@@ -49,10 +80,80 @@ func (s *ConfigBuilder) CommandName(v string) *ConfigBuilder {
 	s.commandName = v
 	return s
 }
+func (s *ConfigBuilder) Aliases(v []string) *ConfigBuilder {
+	s.aliases = v
+	return s
+}
+func (s *ConfigBuilder) Category(v string) *ConfigBuilder {
+	s.category = v
+	return s
+}
+func (s *ConfigBuilder) Interceptors(v []Interceptor) *ConfigBuilder {
+	s.interceptors = v
+	return s
+}
+func (s *ConfigBuilder) Replace(v bool) *ConfigBuilder {
+	s.replace = v
+	return s
+}
+func (s *ConfigBuilder) Hidden(v bool) *ConfigBuilder {
+	s.hidden = v
+	return s
+}
+func (s *ConfigBuilder) Deprecated(v string) *ConfigBuilder {
+	s.deprecated = v
+	return s
+}
+func (s *ConfigBuilder) DeprecatedFlags(v map[string]string) *ConfigBuilder {
+	s.deprecatedFlags = v
+	return s
+}
+func (s *ConfigBuilder) RenamedFlags(v map[string]string) *ConfigBuilder {
+	s.renamedFlags = v
+	return s
+}
+func (s *ConfigBuilder) ParamNames(v []string) *ConfigBuilder {
+	s.paramNames = v
+	return s
+}
+func (s *ConfigBuilder) Doc(v string) *ConfigBuilder {
+	s.doc = v
+	return s
+}
+func (s *ConfigBuilder) Stdout(v io.Writer) *ConfigBuilder {
+	s.stdout = v
+	return s
+}
+func (s *ConfigBuilder) Stderr(v io.Writer) *ConfigBuilder {
+	s.stderr = v
+	return s
+}
+func (s *ConfigBuilder) Stdin(v io.Reader) *ConfigBuilder {
+	s.stdin = v
+	return s
+}
+func (s *ConfigBuilder) Description(v string) *ConfigBuilder {
+	s.description = v
+	return s
+}
 func (s *ConfigBuilder) Build() *Config {
 	return &Config{
-		ErrorHandling: NewConfigItem(s.errorHandling),
-		CommandName:   NewConfigItem(s.commandName),
+		ErrorHandling:   NewConfigItem(s.errorHandling),
+		CommandName:     NewConfigItem(s.commandName),
+		Aliases:         NewConfigItem(s.aliases),
+		Category:        NewConfigItem(s.category),
+		Interceptors:    NewConfigItem(s.interceptors),
+		Replace:         NewConfigItem(s.replace),
+		Hidden:          NewConfigItem(s.hidden),
+		Deprecated:      NewConfigItem(s.deprecated),
+		DeprecatedFlags: NewConfigItem(s.deprecatedFlags),
+		RenamedFlags:    NewConfigItem(s.renamedFlags),
+		ParamNames:      NewConfigItem(s.paramNames),
+		Doc:             NewConfigItem(s.doc),
+		Stdout:          NewConfigItem(s.stdout),
+		Stderr:          NewConfigItem(s.stderr),
+		Stdin:           NewConfigItem(s.stdin),
+		Description:     NewConfigItem(s.description),
 	}
 }
 
@@ -75,3 +176,73 @@ func WithCommandName(v string) Option {
 		c.CommandName.Set(v)
 	}
 }
+func WithAliases(v []string) Option {
+	return func(c *Config) {
+		c.Aliases.Set(v)
+	}
+}
+func WithCategory(v string) Option {
+	return func(c *Config) {
+		c.Category.Set(v)
+	}
+}
+func WithInterceptors(v []Interceptor) Option {
+	return func(c *Config) {
+		c.Interceptors.Set(v)
+	}
+}
+func WithReplace(v bool) Option {
+	return func(c *Config) {
+		c.Replace.Set(v)
+	}
+}
+func WithHidden(v bool) Option {
+	return func(c *Config) {
+		c.Hidden.Set(v)
+	}
+}
+func WithDeprecated(v string) Option {
+	return func(c *Config) {
+		c.Deprecated.Set(v)
+	}
+}
+func WithDeprecatedFlags(v map[string]string) Option {
+	return func(c *Config) {
+		c.DeprecatedFlags.Set(v)
+	}
+}
+func WithRenamedFlags(v map[string]string) Option {
+	return func(c *Config) {
+		c.RenamedFlags.Set(v)
+	}
+}
+func WithParamNames(v []string) Option {
+	return func(c *Config) {
+		c.ParamNames.Set(v)
+	}
+}
+func WithDoc(v string) Option {
+	return func(c *Config) {
+		c.Doc.Set(v)
+	}
+}
+func WithStdout(v io.Writer) Option {
+	return func(c *Config) {
+		c.Stdout.Set(v)
+	}
+}
+func WithStderr(v io.Writer) Option {
+	return func(c *Config) {
+		c.Stderr.Set(v)
+	}
+}
+func WithStdin(v io.Reader) Option {
+	return func(c *Config) {
+		c.Stdin.Set(v)
+	}
+}
+func WithDescription(v string) Option {
+	return func(c *Config) {
+		c.Description.Set(v)
+	}
+}
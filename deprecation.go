@@ -0,0 +1,68 @@
+package fcli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// renamedFlagValue forwards the old flag to the renamed flag.
+type renamedFlagValue struct {
+	flag.Value
+}
+
+func (v *renamedFlagValue) IsBoolFlag() bool {
+	b, ok := v.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// addRenamedFlags defines the old names of the renamed flags in flagSet.
+// The old names are accepted but not printed in usage.
+func (s *targetFunction) addRenamedFlags(flagSet *flag.FlagSet) {
+	for oldName, newName := range s.config.RenamedFlags.Get() {
+		target := flagSet.Lookup(newName)
+		if target == nil || flagSet.Lookup(oldName) != nil {
+			// reported by Validate
+			continue
+		}
+		flagSet.Var(&renamedFlagValue{target.Value}, oldName, "")
+	}
+}
+
+// warnDeprecated prints the warnings of the deprecated command and the flags in visited.
+// visited is the names of the set flags, the old names of the renamed flags are replaced with the new names.
+func (s *targetFunction) warnDeprecated(w io.Writer, visited map[string]bool) {
+	if msg := s.Deprecated(); msg != "" {
+		fmt.Fprintf(w, "Warning: command %s is deprecated: %s\n", s.Name(), msg)
+	}
+	var (
+		renamed    = s.config.RenamedFlags.Get()
+		deprecated = s.config.DeprecatedFlags.Get()
+		names      = make([]string, 0, len(visited))
+	)
+	for name := range visited {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if newName, ok := renamed[name]; ok && s.flagSet.Lookup(name) == nil {
+			fmt.Fprintf(w, "Warning: flag -%s of %s is renamed to -%s\n", name, s.Name(), newName)
+			delete(visited, name)
+			visited[newName] = true
+			name = newName
+		}
+		if msg, ok := deprecated[name]; ok {
+			fmt.Fprintf(w, "Warning: flag -%s of %s is deprecated: %s\n", name, s.Name(), msg)
+		}
+	}
+}
+
+// deprecatedFlagUsage returns the usage of the flag named name if deprecated.
+func (s *targetFunction) deprecatedFlagUsage(name string) (string, bool) {
+	msg, ok := s.config.DeprecatedFlags.Get()[name]
+	if !ok {
+		return "", false
+	}
+	return "Deprecated: " + msg, true
+}
@@ -0,0 +1,166 @@
+package fcli_test
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/berquerant/fcli"
+	"github.com/stretchr/testify/assert"
+)
+
+var deprecationTestRecorder []string
+
+// deprecationTestSync syncs the files.
+func deprecationTestSync(target string, dryRun bool, workers int) {
+	deprecationTestRecorder = append(deprecationTestRecorder, fmt.Sprintf("sync %s %v %d", target, dryRun, workers))
+}
+
+// deprecationTestStatus shows the status.
+func deprecationTestStatus() {
+	deprecationTestRecorder = append(deprecationTestRecorder, "status")
+}
+
+func newDeprecationTestCLI(t *testing.T) fcli.CLI {
+	cli := fcli.NewCLI("tool")
+	cli.OnError(func(error) int { return fcli.Cerror })
+	assert.Nil(t, cli.Add(deprecationTestSync,
+		fcli.WithCommandName("sync"),
+		fcli.WithErrorHandling(flag.ContinueOnError),
+		fcli.WithRenamedFlags(map[string]string{
+			"dry":  "dryRun",
+			"dest": "target",
+		}),
+		fcli.WithDeprecatedFlags(map[string]string{
+			"workers": "ignored",
+		}),
+	))
+	assert.Nil(t, cli.Add(deprecationTestStatus,
+		fcli.WithCommandName("status"),
+		fcli.WithDeprecated("use sync -dryRun"),
+	))
+	assert.Nil(t, cli.Add(deprecationTestStatus,
+		fcli.WithCommandName("secret"),
+		fcli.WithHidden(true),
+	))
+	return cli
+}
+
+func TestCLIDeprecated(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		args    []string
+		want    []string
+		warning string
+	}{
+		{
+			name: "no warnings",
+			args: []string{"sync", "-target", "x", "-dryRun"},
+			want: []string{"sync x true 0"},
+		},
+		{
+			name: "renamed flags",
+			args: []string{"sync", "-dest", "x", "-dry"},
+			want: []string{"sync x true 0"},
+			warning: `Warning: flag -dest of sync is renamed to -target
+Warning: flag -dry of sync is renamed to -dryRun
+`,
+		},
+		{
+			name: "renamed bool flag with value",
+			args: []string{"sync", "-dry=false"},
+			want: []string{"sync  false 0"},
+			warning: `Warning: flag -dry of sync is renamed to -dryRun
+`,
+		},
+		{
+			name: "deprecated flag",
+			args: []string{"sync", "-workers", "2"},
+			want: []string{"sync  false 2"},
+			warning: `Warning: flag -workers of sync is deprecated: ignored
+`,
+		},
+		{
+			name: "deprecated command",
+			args: []string{"status"},
+			want: []string{"status"},
+			warning: `Warning: command status is deprecated: use sync -dryRun
+`,
+		},
+		{
+			name: "hidden command",
+			args: []string{"secret"},
+			want: []string{"status"},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			deprecationTestRecorder = []string{}
+			cli := newDeprecationTestCLI(t)
+			got := captureStderr(t, func() {
+				assert.Nil(t, cli.Start(tc.args...))
+			})
+			assert.Equal(t, tc.want, deprecationTestRecorder)
+			assert.Equal(t, tc.warning, got)
+		})
+	}
+}
+
+func TestCLIHidden(t *testing.T) {
+	cli := newDeprecationTestCLI(t)
+	cli.PrefixMatch(true)
+
+	usage := captureStdout(t, func() {
+		assert.Nil(t, cli.Start("help"))
+	})
+	assert.Equal(t, `Usage: tool <command> [arguments]
+
+Commands:
+  status  (deprecated) deprecationTestStatus shows the status.
+  sync    deprecationTestSync syncs the files.
+
+Run 'tool help <command>' for more information.
+`, usage)
+
+	completion := captureStdout(t, func() {
+		assert.Nil(t, cli.Start("__complete", "s"))
+	})
+	assert.Equal(t, []string{"status", "sync"}, strings.Fields(completion))
+
+	// hidden command is not matched by the prefix
+	assert.ErrorIs(t, cli.Start("se"), fcli.ErrCLICommandNotFound)
+
+	help := captureStdout(t, func() {
+		assert.Nil(t, cli.Start("help", "sync"))
+	})
+	assert.Equal(t, "deprecationTestSync syncs the files.\n"+
+		"Usage of sync:\n"+
+		"  -dryRun\n    \t\n"+
+		"  -target string\n    \t\n"+
+		"  -workers int\n    \tDeprecated: ignored\n", help)
+}
+
+func TestCLIValidateDeprecation(t *testing.T) {
+	cli := fcli.NewCLI("tool")
+	assert.Nil(t, cli.Add(deprecationTestSync,
+		fcli.WithCommandName("sync"),
+		fcli.WithRenamedFlags(map[string]string{
+			"dry":     "dryrun",
+			"workers": "target",
+		}),
+		fcli.WithDeprecatedFlags(map[string]string{
+			"threads": "ignored",
+		}),
+	))
+	err := cli.Validate()
+	var e *fcli.ValidationError
+	if !assert.ErrorAs(t, err, &e) {
+		return
+	}
+	assert.Equal(t, []string{
+		"tool: renamed flag -dry of sync: flag -dryrun not found",
+		"tool: renamed flag -workers of sync clashes with flag -workers",
+		"tool: deprecated flag -threads of sync not found",
+	}, e.Problems)
+}
@@ -0,0 +1,285 @@
+package fcli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/berquerant/fcli/internal/logger"
+)
+
+var (
+	ErrCLIUnsupportedDocFormat = errors.New("unsupported doc format")
+)
+
+// docsCommandName is the hidden command to generate the documents.
+// `tool __docs FORMAT DIR` is the same as GenerateDocs(DIR, FORMAT).
+const docsCommandName = "__docs"
+
+type docFlag struct {
+	Name    string
+	Type    string
+	Default string
+}
+
+type docEntry struct {
+	Name    string
+	Summary string
+	File    string
+}
+
+type docPage struct {
+	Name     string // full path
+	Root     string
+	Summary  string
+	Doc      string
+	Synopsis string
+	Flags    []docFlag
+	Commands []docEntry
+	Parent   *docEntry
+	File     string
+}
+
+func docFileName(path, ext string) string {
+	return strings.ReplaceAll(path, " ", "-") + ext
+}
+
+// docPages returns the pages of s, the groups and the commands.
+func (s *cliMap) docPages(ext string) []*docPage {
+	var (
+		root  = s.root()
+		pages = []*docPage{}
+		page  = &docPage{
+			Name:     s.path(),
+			Root:     root.name,
+			Summary:  summary(s.description()),
+			Doc:      s.description(),
+			Synopsis: fmt.Sprintf("%s <command> [arguments]", s.path()),
+			File:     docFileName(s.path(), ext),
+		}
+	)
+	if s.parent != nil {
+		page.Parent = &docEntry{
+			Name: s.parent.path(),
+			File: docFileName(s.parent.path(), ext),
+		}
+	}
+	if len(s.globals) > 0 {
+		page.Synopsis = fmt.Sprintf("%s [flags] <command> [arguments]", s.path())
+		flagSet, _ := s.newGlobalFlagSet()
+		flagSet.VisitAll(func(f *flag.Flag) {
+			page.Flags = append(page.Flags, docFlag{
+				Name:    f.Name,
+				Type:    globalFlagTypeName(f),
+				Default: f.DefValue,
+			})
+		})
+	}
+	pages = append(pages, page)
+
+	for _, name := range s.visibleNames() {
+		path := fmt.Sprintf("%s %s", s.path(), name)
+		if g, ok := s.groups[name]; ok {
+			page.Commands = append(page.Commands, docEntry{
+				Name:    path,
+				Summary: summary(g.description()),
+				File:    docFileName(path, ext),
+			})
+			pages = append(pages, g.docPages(ext)...)
+			continue
+		}
+		cmd := s.commands[name]
+		page.Commands = append(page.Commands, docEntry{
+			Name:    path,
+			Summary: cmd.docSummary(),
+			File:    docFileName(path, ext),
+		})
+		pages = append(pages, cmd.docPage(path, root.name, page, ext))
+	}
+	return pages
+}
+
+func globalFlagTypeName(f *flag.Flag) string {
+	if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+		return "bool"
+	}
+	name, _ := flag.UnquoteUsage(f)
+	return name
+}
+
+func (s *targetFunction) docPage(path, root string, parent *docPage, ext string) *docPage {
+	page := &docPage{
+		Name:     path,
+		Root:     root,
+		Summary:  s.docSummary(),
+		Doc:      strings.TrimSpace(s.Doc()),
+		Synopsis: path,
+		Parent: &docEntry{
+			Name: parent.Name,
+			File: parent.File,
+		},
+		File: docFileName(path, ext),
+	}
+	if msg := s.Deprecated(); msg != "" {
+		page.Doc = strings.TrimSpace(fmt.Sprintf("Deprecated: %s\n\n%s", msg, page.Doc))
+	}
+	if len(s.flags) > 0 {
+		page.Synopsis = fmt.Sprintf("%s [flags]", path)
+	}
+	for i, f := range s.flags {
+		var def string
+		if x := s.flagSet.Lookup(f.Name()); x != nil {
+			def = x.DefValue
+		}
+		page.Flags = append(page.Flags, docFlag{
+			Name:    f.Name(),
+			Type:    s.flagTypes[i].String(),
+			Default: def,
+		})
+	}
+	return page
+}
+
+// roffEscape escapes the text for troff.
+func roffEscape(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\e`)
+	v = strings.ReplaceAll(v, "-", `\-`)
+	lines := strings.Split(v, "\n")
+	for i, x := range lines {
+		if strings.HasPrefix(x, ".") || strings.HasPrefix(x, "'") {
+			lines[i] = `\&` + x
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+var docTemplates = map[string]*template.Template{
+	"man": template.Must(template.New("man").Funcs(template.FuncMap{
+		"esc":   roffEscape,
+		"upper": strings.ToUpper,
+		"page":  func(v string) string { return strings.ReplaceAll(v, " ", "-") },
+	}).Parse(`.TH "{{upper (page .Name)}}" "1" "" "{{.Root}}" "{{.Root}} Manual"
+.SH NAME
+{{esc (page .Name)}}{{if .Summary}} \- {{esc .Summary}}{{end}}
+.SH SYNOPSIS
+\fB{{esc .Synopsis}}\fP
+{{- if .Doc}}
+.SH DESCRIPTION
+{{esc .Doc}}
+{{- end}}
+{{- if .Commands}}
+.SH COMMANDS
+{{- range .Commands}}
+.TP
+\fB{{esc .Name}}\fP
+{{if .Summary}}{{esc .Summary}}{{else}}See \fB{{esc (page .Name)}}\fP(1).{{end}}
+{{- end}}
+{{- end}}
+{{- if .Flags}}
+.SH OPTIONS
+{{- range .Flags}}
+.TP
+\fB\-{{esc .Name}}\fP \fI{{esc .Type}}\fP
+{{if .Default}}Default: {{esc .Default}}{{else}}No default.{{end}}
+{{- end}}
+{{- end}}
+{{- if or .Parent .Commands}}
+.SH SEE ALSO
+{{- if .Parent}}
+\fB{{esc (page .Parent.Name)}}\fP(1)
+{{- end}}
+{{- range .Commands}}
+\fB{{esc (page .Name)}}\fP(1)
+{{- end}}
+{{- end}}
+`)),
+	"markdown": template.Must(template.New("markdown").Parse(`# {{.Name}}
+{{if .Summary}}
+{{.Summary}}
+{{end}}
+## Synopsis
+
+` + "```" + `
+{{.Synopsis}}
+` + "```" + `
+{{- if .Doc}}
+
+## Description
+
+{{.Doc}}
+{{- end}}
+{{- if .Commands}}
+
+## Commands
+
+| Name | Summary |
+| --- | --- |
+{{- range .Commands}}
+| [{{.Name}}]({{.File}}) | {{.Summary}} |
+{{- end}}
+{{- end}}
+{{- if .Flags}}
+
+## Flags
+
+| Name | Type | Default |
+| --- | --- | --- |
+{{- range .Flags}}
+| ` + "`-{{.Name}}`" + ` | ` + "`{{.Type}}`" + ` | {{if .Default}}` + "`{{.Default}}`" + `{{end}} |
+{{- end}}
+{{- end}}
+{{- if .Parent}}
+
+## See also
+
+- [{{.Parent.Name}}]({{.Parent.File}})
+{{- end}}
+`)),
+}
+
+var docExtensions = map[string]string{
+	"man":      ".1",
+	"markdown": ".md",
+}
+
+func (s *cliMap) GenerateDocs(dir, format string) error {
+	t, ok := docTemplates[format]
+	if !ok {
+		return fmt.Errorf("%w %s", ErrCLIUnsupportedDocFormat, format)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	cliMu.RLock()
+	pages := s.docPages(docExtensions[format])
+	cliMu.RUnlock()
+	for _, page := range pages {
+		if err := s.writeDocPage(t, filepath.Join(dir, page.File), page); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (*cliMap) writeDocPage(t *template.Template, file string, page *docPage) error {
+	logger.Debug("Write doc %s", file)
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return t.Execute(f, page)
+}
+
+func (s *cliMap) generateDocs(args []string) error {
+	if len(args) != 2 {
+		cliMu.RLock()
+		defer cliMu.RUnlock()
+		return fmt.Errorf("%w %s %s FORMAT DIR", ErrCLINotEnoughArguments, s.path(), docsCommandName)
+	}
+	return s.GenerateDocs(args[1], args[0])
+}
@@ -0,0 +1,98 @@
+package fcli_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/berquerant/fcli"
+	"github.com/stretchr/testify/assert"
+)
+
+// docsTestMigrate migrates the database. Run it after deploy.
+func docsTestMigrate(version int, dryRun bool) {}
+
+func newDocsTestCLI(t *testing.T) fcli.CLI {
+	cli := fcli.NewCLI("tool")
+	assert.Nil(t, cli.AddGlobalFlag("config", "", ""))
+	assert.Nil(t, cli.Group("db").Add(docsTestMigrate, fcli.WithCommandName("migrate")))
+	return cli
+}
+
+func TestCLIGenerateDocs(t *testing.T) {
+	for _, tc := range []struct {
+		format string
+		files  map[string][]string // file name to expected substrings
+	}{
+		{
+			format: "man",
+			files: map[string][]string{
+				"tool.1": {
+					`.TH "TOOL" "1" "" "tool" "tool Manual"`,
+					`\fBtool [flags] <command> [arguments]\fP`,
+					`\fB\-config\fP \fIstring\fP`,
+					`\fBtool\-db\fP(1)`,
+				},
+				"tool-db.1": {
+					`\fBtool db migrate\fP`,
+					`docsTestMigrate migrates the database.`,
+				},
+				"tool-db-migrate.1": {
+					`tool\-db\-migrate \- docsTestMigrate migrates the database.`,
+					`Run it after deploy.`,
+					`\fB\-version\fP \fIint\fP`,
+					`Default: 0`,
+					`\fB\-dryRun\fP \fIbool\fP`,
+					`Default: false`,
+				},
+			},
+		},
+		{
+			format: "markdown",
+			files: map[string][]string{
+				"tool.md": {
+					"# tool",
+					"| [tool db](tool-db.md) |  |",
+					"| `-config` | `string` |  |",
+				},
+				"tool-db.md": {
+					"| [tool db migrate](tool-db-migrate.md) | docsTestMigrate migrates the database. |",
+					"- [tool](tool.md)",
+				},
+				"tool-db-migrate.md": {
+					"tool db migrate [flags]",
+					"| `-version` | `int` | `0` |",
+					"- [tool db](tool-db.md)",
+				},
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.format, func(t *testing.T) {
+			dir := t.TempDir()
+			assert.Nil(t, newDocsTestCLI(t).GenerateDocs(dir, tc.format))
+			entries, err := os.ReadDir(dir)
+			if !assert.Nil(t, err) {
+				return
+			}
+			assert.Equal(t, len(tc.files), len(entries))
+			for name, wants := range tc.files {
+				b, err := os.ReadFile(filepath.Join(dir, name))
+				if !assert.Nil(t, err, name) {
+					continue
+				}
+				for _, want := range wants {
+					assert.Contains(t, string(b), want, name)
+				}
+			}
+		})
+	}
+}
+
+func TestCLIGenerateDocsCommand(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, newDocsTestCLI(t).Start("__docs", "markdown", dir))
+	_, err := os.Stat(filepath.Join(dir, "tool-db-migrate.md"))
+	assert.Nil(t, err)
+	assert.ErrorIs(t, newDocsTestCLI(t).GenerateDocs(dir, "html"), fcli.ErrCLIUnsupportedDocFormat)
+}
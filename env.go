@@ -0,0 +1,53 @@
+package fcli
+
+import (
+	"context"
+	"os"
+	"sort"
+)
+
+type envKey struct{}
+
+// WithEnv returns the context with which the functions read env by Getenv
+// and the plugins get env in addition to the environment variables of the process.
+// Intended for the tests, see fclitest.
+func WithEnv(ctx context.Context, env map[string]string) context.Context {
+	m := map[string]string{}
+	if parent, ok := ctx.Value(envKey{}).(map[string]string); ok {
+		for k, v := range parent {
+			m[k] = v
+		}
+	}
+	for k, v := range env {
+		m[k] = v
+	}
+	return context.WithValue(ctx, envKey{}, m)
+}
+
+// Getenv returns the environment variable named key set by WithEnv, os.Getenv if not set.
+func Getenv(ctx context.Context, key string) string {
+	if m, ok := ctx.Value(envKey{}).(map[string]string); ok {
+		if v, ok := m[key]; ok {
+			return v
+		}
+	}
+	return os.Getenv(key)
+}
+
+// environ returns the environment variables of the process and set by WithEnv, like os.Environ.
+func environ(ctx context.Context) []string {
+	env := os.Environ()
+	m, ok := ctx.Value(envKey{}).(map[string]string)
+	if !ok {
+		return env
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		env = append(env, k+"="+m[k])
+	}
+	return env
+}
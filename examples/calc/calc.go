@@ -78,7 +78,5 @@ func main() {
 	fail(cli.Add(sum))
 	fail(cli.Add(mult))
 	fail(cli.Add(intPower, fcli.WithCommandName("pow")))
-	if cli.Start() != nil {
-		os.Exit(1)
-	}
+	os.Exit(cli.Run())
 }
@@ -38,6 +38,8 @@ func main() {
 		}
 		return fcli.Cerror
 	})
+	// cancel ctx on SIGINT, SIGTERM
+	cli.HandleSignals(time.Second)
 	_ = cli.Add(sqrt)
 	_ = cli.Add(wait)
 	if err := cli.StartWithContext(ctx); err != nil {
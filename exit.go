@@ -0,0 +1,100 @@
+package fcli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+)
+
+// ExitCoder is implemented by the errors that specify the exit status.
+type ExitCoder interface {
+	ExitCode() int
+}
+
+// Default exit status by ExitCode.
+const (
+	ExitCodeSuccess = 0
+	// ExitCodeHelp is for the help requested by -h.
+	// Same as ExitCodeSuccess on purpose, like flag.ExitOnError,
+	// the help is what the user asked for.
+	ExitCodeHelp = 0
+	// ExitCodeError is for the error returned by the function.
+	ExitCodeError = 1
+	// ExitCodeUsage is for the wrong command name or not enough arguments.
+	ExitCodeUsage = 2
+	// ExitCodeParse is for ErrParseFailure and ErrCLIInvalidGlobalFlag.
+	ExitCodeParse = 3
+	// ExitCodeCallFailure is for ErrCallFailure except ErrParseFailure.
+	ExitCodeCallFailure = 4
+)
+
+// ExitError is the error with the exit status.
+// Returned if the function returns (int, error) and the int is not 0.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) ExitCode() int { return e.Code }
+func (e *ExitError) Unwrap() error { return e.Err }
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return fmt.Sprintf("exit status %d %v", e.Code, e.Err)
+}
+
+func (e *InterruptedError) ExitCode() int { return signalExitCode(e.Signal) }
+
+// ExitCode returns the exit status for err.
+// Returns ExitCoder.ExitCode() if err implements ExitCoder.
+// The help is not distinguished from the success, ExitCodeHelp is 0 on purpose,
+// the others have the distinct codes.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitCodeSuccess
+	}
+	var c ExitCoder
+	if errors.As(err, &c) {
+		return c.ExitCode()
+	}
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		return ExitCodeHelp
+	case errors.Is(err, ErrParseFailure), errors.Is(err, ErrCLIInvalidGlobalFlag):
+		return ExitCodeParse
+	case errors.Is(err, ErrCLINotEnoughArguments),
+		errors.Is(err, ErrCLICommandNotFound),
+		errors.Is(err, ErrCLIAmbiguousCommand):
+		return ExitCodeUsage
+	case errors.Is(err, ErrCallFailure):
+		return ExitCodeCallFailure
+	default:
+		return ExitCodeError
+	}
+}
+
+type noExitKey struct{}
+
+// NoExit returns the context with which StartWithContext does not exit by the flag errors of the functions
+// regardless of ErrorHandling, like Run.
+// Intended for the tests, see fclitest.
+func NoExit(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noExitKey{}, true)
+}
+
+// isNoExit returns true if ctx is made by NoExit, e.g. called by Run or REPL.
+// They do not exit by the flag errors of the functions regardless of ErrorHandling.
+func isNoExit(ctx context.Context) bool {
+	v, _ := ctx.Value(noExitKey{}).(bool)
+	return v
+}
+
+func (s *cliMap) Run(arguments ...string) int {
+	return s.RunWithContext(context.Background(), arguments...)
+}
+
+func (s *cliMap) RunWithContext(ctx context.Context, arguments ...string) int {
+	return ExitCode(s.StartWithContext(NoExit(ctx), arguments...))
+}
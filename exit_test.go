@@ -0,0 +1,152 @@
+package fcli_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/berquerant/fcli"
+	"github.com/stretchr/testify/assert"
+)
+
+var errExitTest = errors.New("exit test")
+
+func exitTestCode(code int, fail bool) (int, error) {
+	if fail {
+		return code, errExitTest
+	}
+	return code, nil
+}
+
+func exitTestError() error { return errExitTest }
+
+func exitTestPanic() { panic("exit test") }
+
+func TestCLIRun(t *testing.T) {
+	newCLI := func(t *testing.T) fcli.CLI {
+		cli := fcli.NewCLI("tool")
+		cli.OnError(func(error) int { return fcli.Cerror })
+		assert.Nil(t, cli.AddGlobalFlag("v", false, ""))
+		// ExitOnError is default but Run never exits
+		assert.Nil(t, cli.Add(exitTestCode, fcli.WithCommandName("code")))
+		assert.Nil(t, cli.Add(exitTestError, fcli.WithCommandName("error")))
+		assert.Nil(t, cli.Add(exitTestPanic, fcli.WithCommandName("panic")))
+		return cli
+	}
+
+	for _, tc := range []struct {
+		name string
+		args []string
+		want int
+	}{
+		{
+			name: "success",
+			args: []string{"code"},
+			want: fcli.ExitCodeSuccess,
+		},
+		{
+			name: "returned code",
+			args: []string{"code", "-code", "10"},
+			want: 10,
+		},
+		{
+			name: "returned code and error",
+			args: []string{"code", "-code", "11", "-fail"},
+			want: 11,
+		},
+		{
+			name: "returned error without code",
+			args: []string{"code", "-fail"},
+			want: fcli.ExitCodeError,
+		},
+		{
+			name: "returned error",
+			args: []string{"error"},
+			want: fcli.ExitCodeError,
+		},
+		{
+			name: "not enough arguments",
+			args: []string{},
+			want: fcli.ExitCodeUsage,
+		},
+		{
+			name: "command not found",
+			args: []string{"unknown"},
+			want: fcli.ExitCodeUsage,
+		},
+		{
+			name: "unknown flag",
+			args: []string{"code", "-unknown"},
+			want: fcli.ExitCodeParse,
+		},
+		{
+			name: "invalid flag value",
+			args: []string{"code", "-code", "x"},
+			want: fcli.ExitCodeParse,
+		},
+		{
+			name: "unknown global flag",
+			args: []string{"-unknown", "code"},
+			want: fcli.ExitCodeParse,
+		},
+		{
+			name: "help",
+			args: []string{"code", "-h"},
+			want: fcli.ExitCodeHelp,
+		},
+		{
+			name: "call failure",
+			args: []string{"panic"},
+			want: fcli.ExitCodeCallFailure,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, newCLI(t).Run(tc.args...))
+		})
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	assert.Equal(t, 0, fcli.ExitCode(nil))
+	assert.Equal(t, 5, fcli.ExitCode(&fcli.ExitError{Code: 5}))
+	err := &fcli.ExitError{Code: 6, Err: errExitTest}
+	assert.Equal(t, 6, fcli.ExitCode(err))
+	assert.ErrorIs(t, err, errExitTest)
+	assert.ErrorIs(t, fcli.ErrParseFailure, fcli.ErrCallFailure)
+}
+
+func TestCLIRunExitStatusOutput(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		args    []string
+		want    int
+		wantErr string
+	}{
+		{
+			name: "exit status without error",
+			args: []string{"code", "-code", "1"},
+			want: 1,
+		},
+		{
+			name:    "exit status with error",
+			args:    []string{"code", "-code", "1", "-fail"},
+			want:    1,
+			wantErr: "Error: exit status 1 exit test\n",
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var stderr bytes.Buffer
+			cli := fcli.NewCLI("tool", fcli.WithStderr(&stderr))
+			assert.Nil(t, cli.Add(exitTestCode, fcli.WithCommandName("code")))
+			assert.Equal(t, tc.want, cli.Run(tc.args...))
+			if tc.wantErr == "" {
+				assert.Equal(t, "", stderr.String())
+				return
+			}
+			assert.True(t, strings.HasPrefix(stderr.String(), tc.wantErr), stderr.String())
+		})
+	}
+}
@@ -0,0 +1,98 @@
+// Package fclitest provides utilities for testing the CLI in-process.
+package fclitest
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/berquerant/fcli"
+)
+
+// UpdateEnv is the environment variable to write the golden files instead of comparing,
+// like `FCLITEST_UPDATE=1 go test`.
+const UpdateEnv = "FCLITEST_UPDATE"
+
+// Case is the input of Run.
+type Case struct {
+	// Args is the arguments except the program name.
+	// nil is the same as the empty, os.Args is never read.
+	Args []string
+	// Env is the environment variables read by fcli.Getenv and passed to the plugins, see fcli.WithEnv.
+	// os.Getenv does not read them.
+	Env map[string]string
+	// Stdin is the content of stdin.
+	Stdin string
+}
+
+// Result is the output of Run.
+type Result struct {
+	Stdout string
+	Stderr string
+	// Code is the exit status by fcli.ExitCode.
+	Code int
+	// Err is the error returned by the CLI.
+	Err error
+}
+
+// Run runs cli with c in-process and captures stdout and stderr.
+//
+// The streams are given to cli by fcli.WithStreams instead of the streams of cli,
+// so captures the output of fcli and the functions writing to fcli.Stdout and fcli.Stderr,
+// not to os.Stdout and os.Stderr.
+// Does not exit even if ErrorHandling of the function is flag.ExitOnError, see fcli.NoExit.
+// Does not modify the process, so can be used in the parallel tests.
+func Run(t *testing.T, cli fcli.CLI, c Case) *Result {
+	t.Helper()
+	return RunWithContext(context.Background(), t, cli, c)
+}
+
+// RunWithContext is Run with ctx.
+func RunWithContext(ctx context.Context, t *testing.T, cli fcli.CLI, c Case) *Result {
+	t.Helper()
+	args := c.Args
+	if args == nil {
+		// nil reads os.Args
+		args = []string{}
+	}
+
+	var stdout, stderr bytes.Buffer
+	ctx = fcli.WithStreams(ctx, strings.NewReader(c.Stdin), &stdout, &stderr)
+	if len(c.Env) > 0 {
+		ctx = fcli.WithEnv(ctx, c.Env)
+	}
+	err := cli.StartWithContext(fcli.NoExit(ctx), args...)
+
+	return &Result{
+		Stdout: stdout.String(),
+		Stderr: stderr.String(),
+		Code:   fcli.ExitCode(err),
+		Err:    err,
+	}
+}
+
+// Golden compares got with the golden file testdata/name.golden.
+// Writes got to the golden file instead if UpdateEnv is 1.
+func Golden(t *testing.T, name, got string) {
+	t.Helper()
+	file := filepath.Join("testdata", name+".golden")
+	if os.Getenv(UpdateEnv) == "1" {
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatalf("fclitest: create dir %s %v", file, err)
+		}
+		if err := os.WriteFile(file, []byte(got), 0o644); err != nil {
+			t.Fatalf("fclitest: write golden %s %v", file, err)
+		}
+		return
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("fclitest: read golden %s %v, run with %s=1 to create", file, err, UpdateEnv)
+	}
+	if want := string(b); got != want {
+		t.Errorf("fclitest: mismatch golden %s\n--- want\n%s\n--- got\n%s", file, want, got)
+	}
+}
@@ -0,0 +1,182 @@
+package fclitest_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/berquerant/fcli"
+	"github.com/berquerant/fcli/fclitest"
+	"github.com/stretchr/testify/assert"
+)
+
+// greet greets the user named by GREETING_NAME if name is empty.
+func greet(ctx context.Context, name string, times int) {
+	if name == "" {
+		name = fcli.Getenv(ctx, "GREETING_NAME")
+	}
+	for i := 0; i < times; i++ {
+		fmt.Fprintln(fcli.Stdout(ctx), "Hello,", name)
+	}
+}
+
+var errNoLines = errors.New("no lines")
+
+// count counts the lines of stdin.
+func count(ctx context.Context) (int, error) {
+	var n int
+	s := bufio.NewScanner(fcli.Stdin(ctx))
+	for s.Scan() {
+		n++
+	}
+	fmt.Fprintln(fcli.Stdout(ctx), n)
+	if n == 0 {
+		return 0, errNoLines
+	}
+	return 0, nil
+}
+
+func newCLI() fcli.CLI {
+	cli := fcli.NewCLI("tool")
+	_ = cli.Add(greet)
+	_ = cli.Add(count)
+	return cli
+}
+
+func TestRun(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		c    fclitest.Case
+		want fclitest.Result
+		err  error
+	}{
+		{
+			name: "greet",
+			c: fclitest.Case{
+				Args: []string{"greet", "-name", "alice", "-times", "2"},
+			},
+			want: fclitest.Result{
+				Stdout: "Hello, alice\nHello, alice\n",
+			},
+		},
+		{
+			name: "env",
+			c: fclitest.Case{
+				Args: []string{"greet", "-times", "1"},
+				Env:  map[string]string{"GREETING_NAME": "bob"},
+			},
+			want: fclitest.Result{
+				Stdout: "Hello, bob\n",
+			},
+		},
+		{
+			name: "stdin",
+			c: fclitest.Case{
+				Args:  []string{"count"},
+				Stdin: "a\nb\nc\n",
+			},
+			want: fclitest.Result{
+				Stdout: "3\n",
+			},
+		},
+		{
+			name: "error",
+			c: fclitest.Case{
+				Args: []string{"count"},
+			},
+			want: fclitest.Result{
+				Stdout: "0\n",
+				Code:   fcli.ExitCodeError,
+			},
+			err: errNoLines,
+		},
+		{
+			name: "flag error does not exit",
+			c: fclitest.Case{
+				Args: []string{"greet", "-times", "x"},
+			},
+			want: fclitest.Result{
+				Code: fcli.ExitCodeParse,
+			},
+			err: fcli.ErrParseFailure,
+		},
+		{
+			name: "no args",
+			want: fclitest.Result{
+				Code: fcli.ExitCodeUsage,
+			},
+			err: fcli.ErrCLINotEnoughArguments,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got := fclitest.Run(t, newCLI(), tc.c)
+			assert.ErrorIs(t, got.Err, tc.err)
+			assert.Equal(t, tc.want.Stdout, got.Stdout)
+			assert.Equal(t, tc.want.Code, got.Code)
+			if tc.err == nil {
+				assert.Equal(t, "", got.Stderr)
+			} else {
+				assert.NotEqual(t, "", got.Stderr)
+			}
+		})
+	}
+}
+
+func TestRunReplacesStreams(t *testing.T) {
+	var configured bytes.Buffer
+	cli := fcli.NewCLI("tool", fcli.WithStdout(&configured), fcli.WithStderr(&configured))
+	_ = cli.Add(greet)
+
+	for i := 0; i < 3; i++ {
+		i := i
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			t.Parallel()
+			name := fmt.Sprintf("user%d", i)
+			got := fclitest.Run(t, cli, fclitest.Case{
+				Args: []string{"greet", "-times", "1"},
+				Env:  map[string]string{"GREETING_NAME": name},
+			})
+			assert.Nil(t, got.Err)
+			assert.Equal(t, "Hello, "+name+"\n", got.Stdout)
+			assert.Equal(t, "", got.Stderr)
+		})
+	}
+	t.Cleanup(func() {
+		assert.Equal(t, "", configured.String())
+	})
+}
+
+func TestGolden(t *testing.T) {
+	got := fclitest.Run(t, newCLI(), fclitest.Case{
+		Args: []string{"help"},
+	})
+	assert.Nil(t, got.Err)
+	fclitest.Golden(t, "help", got.Stdout)
+
+	got = fclitest.Run(t, newCLI(), fclitest.Case{
+		Args: []string{"greet", "-h"},
+	})
+	assert.Equal(t, fcli.ExitCodeHelp, got.Code)
+	fclitest.Golden(t, "greet_help", got.Stderr)
+}
+
+func TestGoldenUpdate(t *testing.T) {
+	const name = "update"
+	file := filepath.Join("testdata", name+".golden")
+	defer os.Remove(file)
+
+	t.Setenv(fclitest.UpdateEnv, "1")
+	fclitest.Golden(t, name, "updated\n")
+	b, err := os.ReadFile(file)
+	assert.Nil(t, err)
+	assert.Equal(t, "updated\n", string(b))
+
+	t.Setenv(fclitest.UpdateEnv, "")
+	fclitest.Golden(t, name, "updated\n")
+}
@@ -438,6 +438,13 @@ type CustomFlagZeroer interface {
 	FlagZero() CustomFlagUnmarshaller
 }
 
+// CustomFlagJSONSchemaer provides the JSON Schema of the value in the spec of CLI.
+// Called on the zero value like CustomFlagZeroer.
+// Without it, the schema of the custom flag is a string.
+type CustomFlagJSONSchemaer interface {
+	FlagJSONSchema() map[string]any
+}
+
 // CustomFlag is the flag for types that implement CustomFlagUnmarshaller.
 type CustomFlag struct {
 	*baseFlag
@@ -0,0 +1,204 @@
+package fcli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/berquerant/fcli/internal/logger"
+)
+
+var (
+	ErrCLIInvalidGlobalFlag = errors.New("invalid global flag")
+)
+
+type globalFlagsKey struct{}
+
+// GlobalFlag returns the value of the global flag named name parsed by CLI.
+// Returns false if no such flag.
+func GlobalFlag(ctx context.Context, name string) (any, bool) {
+	m, ok := ctx.Value(globalFlagsKey{}).(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	v, ok := m[name]
+	return v, ok
+}
+
+func withGlobalFlags(ctx context.Context, values map[string]any) context.Context {
+	m := map[string]any{}
+	if parent, ok := ctx.Value(globalFlagsKey{}).(map[string]any); ok {
+		for k, v := range parent {
+			m[k] = v
+		}
+	}
+	for k, v := range values {
+		m[k] = v
+	}
+	return context.WithValue(ctx, globalFlagsKey{}, m)
+}
+
+type globalFlag struct {
+	name    string
+	typ     reflect.Type
+	factory FlagFactory
+	// value is the default value
+	value any
+	usage string
+}
+
+// newGlobalFlagSet returns a new flag set and the flags defined in it.
+func (s *cliMap) newGlobalFlagSet() (*flag.FlagSet, []Flag) {
+	var (
+		flagSet = flag.NewFlagSet(s.path(), flag.ContinueOnError)
+		flags   = make([]Flag, len(s.globals))
+	)
+	flagSet.SetOutput(io.Discard)
+	flagSet.Usage = NilUsage
+	for i, g := range s.globals {
+		flags[i] = g.factory(g.name)
+		flags[i].AddFlag(flagSet)
+		x := flagSet.Lookup(g.name)
+		x.Usage = g.usage
+		if text, ok := defaultText(g.value); ok {
+			x.DefValue = text
+		}
+	}
+	return flagSet, flags
+}
+
+// defaultText returns the default value of the flag printed in usage.
+// Returns false if v is the zero value or cannot be printed.
+func defaultText(v any) (string, bool) {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || rv.IsZero() {
+		return "", false
+	}
+	if x, ok := v.(fmt.Stringer); ok {
+		return x.String(), true
+	}
+	switch rv.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return fmt.Sprint(v), true
+	default:
+		return "", false
+	}
+}
+
+func (s *cliMap) hasGlobalFlags() bool {
+	cliMu.RLock()
+	defer cliMu.RUnlock()
+	return len(s.globals) > 0
+}
+
+// parseGlobalFlags parses the global flags before the command name.
+// Returns the parsed values and the rest arguments.
+func (s *cliMap) parseGlobalFlags(ctx context.Context, args []string) (map[string]any, []string, error) {
+	cliMu.RLock()
+	var (
+		flagSet, flags = s.newGlobalFlagSet()
+		path           = s.path()
+		hasDefault     = s.defaultCommand != ""
+		defaults       = make([]any, len(s.globals))
+	)
+	for i, g := range s.globals {
+		defaults[i] = g.value
+	}
+	flagSet.Usage = s.usageFunc(ctx)
+	cliMu.RUnlock()
+
+	var passed []string
+	if hasDefault {
+		// the unknown flags are for the default command, like `tool -v -name world`
+		args, passed = splitGlobalFlags(flagSet, args)
+	}
+	if err := flagSet.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, nil, fmt.Errorf("%w %s", flag.ErrHelp, path)
+		}
+		return nil, nil, fmt.Errorf("%w %s %v", ErrCLIInvalidGlobalFlag, path, err)
+	}
+	visited := map[string]bool{}
+	flagSet.Visit(func(f *flag.Flag) {
+		visited[f.Name] = true
+	})
+	values := make(map[string]any, len(flags))
+	for i, f := range flags {
+		if !visited[f.Name()] {
+			values[f.Name()] = defaults[i]
+			continue
+		}
+		v, err := f.Unwrap()
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w %s %s %v", ErrCLIInvalidGlobalFlag, path, f.Name(), err)
+		}
+		values[f.Name()] = v
+	}
+	logger.Debug("Global flags %s %#v", path, values)
+	return values, append(flagSet.Args(), passed...), nil
+}
+
+// splitGlobalFlags splits args into the global flags and the rest at the first unknown flag.
+// -h and -help are the global flags to print usage.
+func splitGlobalFlags(flagSet *flag.FlagSet, args []string) ([]string, []string) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || len(arg) < 2 || arg[0] != '-' {
+			return args, nil
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if name == "h" || name == "help" {
+			return args, nil
+		}
+		f := flagSet.Lookup(name)
+		if f == nil {
+			return args[:i], args[i:]
+		}
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); hasValue || (ok && b.IsBoolFlag()) {
+			continue
+		}
+		// skip the value
+		i++
+	}
+	return args, nil
+}
+
+// writeGlobalFlags prints the global flags if exist.
+func (s *cliMap) writeGlobalFlags(w io.Writer) {
+	if len(s.globals) == 0 {
+		return
+	}
+	flagSet, _ := s.newGlobalFlagSet()
+	flagSet.SetOutput(w)
+	fmt.Fprintf(w, "Flags:\n")
+	flagSet.PrintDefaults()
+}
+
+func (s *cliMap) AddGlobalFlag(name string, value any, usage string) error {
+	factory, ok := NewFlagFactory(value)
+	if !ok {
+		return fmt.Errorf("%w %s unsupported type %T", ErrCLIInvalidGlobalFlag, name, value)
+	}
+	cliMu.Lock()
+	defer cliMu.Unlock()
+	for _, g := range s.globals {
+		if g.name == name {
+			return fmt.Errorf("%w %s already defined", ErrCLIInvalidGlobalFlag, name)
+		}
+	}
+	s.globals = append(s.globals, &globalFlag{
+		name:    name,
+		typ:     reflect.TypeOf(value),
+		factory: factory,
+		value:   value,
+		usage:   usage,
+	})
+	return nil
+}
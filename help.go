@@ -0,0 +1,286 @@
+package fcli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/berquerant/fcli/internal/logger"
+)
+
+const helpCommandName = "help"
+
+// CommandOrder is the order of the commands in usage.
+type CommandOrder int
+
+const (
+	// inherit the order of the parent
+	orderUnknown CommandOrder = iota
+	// OrderAlphabetical sorts the commands by the names.
+	OrderAlphabetical
+	// OrderRegistration lists the commands in the order they were added.
+	OrderRegistration
+)
+
+// CategoryOther is the heading of the commands without the category in usage
+// if any command has the category.
+const CategoryOther = "Other"
+
+// sortCategories sorts the categories by the category order.
+// The categories not in the order follow alphabetically, CategoryOther is the last of them.
+func (s *cliMap) sortCategories(categories []string) {
+	rank := map[string]int{}
+	for i, x := range s.inheritedCategoryOrder() {
+		rank[x] = i
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		var (
+			a, b    = categories[i], categories[j]
+			ra, okA = rank[a]
+			rb, okB = rank[b]
+		)
+		switch {
+		case okA && okB:
+			return ra < rb
+		case okA || okB:
+			return okA
+		case (a == CategoryOther) != (b == CategoryOther):
+			return b == CategoryOther
+		default:
+			return a < b
+		}
+	})
+}
+
+func (s *cliMap) inheritedCategoryOrder() []string {
+	for x := s; x != nil; x = x.parent {
+		if x.categoryOrder != nil {
+			return x.categoryOrder
+		}
+	}
+	return nil
+}
+
+func (s *cliMap) CategoryOrder(categories ...string) {
+	cliMu.Lock()
+	defer cliMu.Unlock()
+	s.categoryOrder = append([]string{}, categories...)
+}
+
+func (s *cliMap) commandOrder() CommandOrder {
+	for x := s; x != nil; x = x.parent {
+		if x.order != orderUnknown {
+			return x.order
+		}
+	}
+	return OrderAlphabetical
+}
+
+// description returns the text set by WithDescription.
+func (s *cliMap) description() string {
+	return strings.TrimSpace(s.config.Description.Get())
+}
+
+// sortedNames returns the names of the commands and the groups in the order.
+func (s *cliMap) sortedNames() []string {
+	names := make([]string, len(s.names))
+	copy(names, s.names)
+	if s.commandOrder() == OrderAlphabetical {
+		sort.Strings(names)
+	}
+	return names
+}
+
+// visibleNames returns sortedNames except the hidden commands.
+func (s *cliMap) visibleNames() []string {
+	names := []string{}
+	for _, name := range s.sortedNames() {
+		if !s.isHidden(name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// isHidden returns true if name is the command hidden by WithHidden.
+func (s *cliMap) isHidden(name string) bool {
+	cmd, ok := s.commands[name]
+	return ok && cmd.Hidden()
+}
+
+// summary returns the first sentence of the first paragraph of the doc.
+func summary(doc string) string {
+	doc = strings.TrimSpace(doc)
+	if i := strings.Index(doc, "\n\n"); i >= 0 {
+		doc = doc[:i]
+	}
+	doc = strings.Join(strings.Fields(doc), " ")
+	for i, c := range doc {
+		switch c {
+		case '.', '!', '?':
+			if i+1 == len(doc) || doc[i+1] == ' ' {
+				return doc[:i+1]
+			}
+		}
+	}
+	return doc
+}
+
+// docSummary returns the summary of the doc, marked if deprecated.
+func (s *targetFunction) docSummary() string {
+	if s.Deprecated() != "" {
+		return strings.TrimSpace("(deprecated) " + summary(s.Doc()))
+	}
+	return summary(s.Doc())
+}
+
+// writeCommands prints the commands with the summaries, grouped by the categories.
+func (s *cliMap) writeCommands(w io.Writer) {
+	var (
+		categories = []string{}
+		rows       = map[string][][2]string{}
+	)
+	for _, name := range s.visibleNames() {
+		var (
+			category string
+			x        = "(group)"
+		)
+		if cmd, ok := s.commands[name]; ok {
+			category = cmd.Category()
+			x = cmd.docSummary()
+		} else if desc := s.groups[name].description(); desc != "" {
+			x = summary(desc)
+		}
+		if _, ok := rows[category]; !ok {
+			categories = append(categories, category)
+		}
+		rows[category] = append(rows[category], [2]string{name, x})
+	}
+
+	if len(categories) == 1 && categories[0] == "" {
+		fmt.Fprintf(w, "\nCommands:\n")
+		writeTable(w, rows[""])
+		return
+	}
+	if uncategorized, ok := rows[""]; ok {
+		// the uncategorized commands go to the other bucket
+		if _, ok := rows[CategoryOther]; !ok {
+			categories = append(categories, CategoryOther)
+		}
+		rows[CategoryOther] = append(rows[CategoryOther], uncategorized...)
+	}
+	filtered := []string{}
+	for _, category := range categories {
+		if category != "" {
+			filtered = append(filtered, category)
+		}
+	}
+	s.sortCategories(filtered)
+
+	for _, category := range filtered {
+		fmt.Fprintf(w, "\n%s:\n", category)
+		writeTable(w, rows[category])
+	}
+}
+
+// writeTable prints the rows in 2 aligned columns.
+func writeTable(w io.Writer, rows [][2]string) {
+	var width int
+	for _, r := range rows {
+		if len(r[0]) > width {
+			width = len(r[0])
+		}
+	}
+	for _, r := range rows {
+		fmt.Fprintln(w, strings.TrimRight(fmt.Sprintf("  %-*s  %s", width, r[0], r[1]), " "))
+	}
+}
+
+// writeUsage prints the commands, the groups, the global flags and the help topics.
+func (s *cliMap) writeUsage(w io.Writer) {
+	if len(s.globals) > 0 {
+		fmt.Fprintf(w, "Usage: %s [flags] <command> [arguments]\n", s.path())
+	} else {
+		fmt.Fprintf(w, "Usage: %s <command> [arguments]\n", s.path())
+	}
+	if desc := s.description(); desc != "" {
+		fmt.Fprintf(w, "\n%s\n", desc)
+	}
+
+	s.writeCommands(w)
+
+	if plugins := s.pluginNames(); len(plugins) > 0 {
+		fmt.Fprintf(w, "\nPlugins:\n")
+		for _, x := range plugins {
+			fmt.Fprintf(w, "  %s\n", x)
+		}
+	}
+
+	if len(s.globals) > 0 {
+		fmt.Fprintln(w)
+		s.writeGlobalFlags(w)
+	}
+
+	if len(s.topics) > 0 {
+		topics := make([]string, 0, len(s.topics))
+		for k := range s.topics {
+			topics = append(topics, k)
+		}
+		sort.Strings(topics)
+		fmt.Fprintf(w, "\nHelp topics:\n")
+		for _, x := range topics {
+			fmt.Fprintf(w, "  %s\n", x)
+		}
+	}
+
+	fmt.Fprintf(w, "\nRun '%s %s <command>' for more information.\n", s.path(), helpCommandName)
+}
+
+// help prints the help of the command, the group or the topic selected by args.
+// Prints to w.
+func (s *cliMap) help(w io.Writer, args []string) (*cliMap, error) {
+	cliMu.RLock()
+	defer cliMu.RUnlock()
+	target := s
+	for i, arg := range args {
+		if text, ok := target.topics[arg]; ok && !target.isCommandName(arg) {
+			fmt.Fprint(w, text)
+			if !strings.HasSuffix(text, "\n") {
+				fmt.Fprintln(w)
+			}
+			return target, nil
+		}
+		name, err := target.resolve(arg)
+		if err != nil {
+			return target, err
+		}
+		if g, ok := target.groups[name]; ok {
+			target = g
+			continue
+		}
+		logger.Debug("Help %s %s", target.path(), name)
+		if rest := args[i+1:]; len(rest) > 0 {
+			return target, fmt.Errorf("%w %s %s unexpected arguments %v", ErrCLICommandNotFound, target.path(), name, rest)
+		}
+		target.commands[name].writeUsage(w)
+		return target, nil
+	}
+	target.writeUsage(w)
+	return target, nil
+}
+
+// isCommandName returns true if name is the command, the group or the alias.
+// Requires cliMu.
+func (s *cliMap) isCommandName(name string) bool {
+	_, isCommand := s.commands[name]
+	_, isGroup := s.groups[name]
+	_, isAlias := s.aliases[name]
+	return isCommand || isGroup || isAlias
+}
+
+func (s *cliMap) AddHelpTopic(name, text string) {
+	cliMu.Lock()
+	defer cliMu.Unlock()
+	s.topics[name] = text
+}
@@ -0,0 +1,34 @@
+package fcli
+
+import "context"
+
+// Invocation is the call of the function seen by Interceptor.
+type Invocation struct {
+	// Name is the command name.
+	Name string
+	// Arguments is the command-line arguments passed to the function.
+	Arguments []string
+	// Values is the parsed values of the flags by the names.
+	Values map[string]any
+}
+
+// Interceptor wraps the call of the function after the flags are parsed.
+// Call next to call the rest of the interceptors and the function, and get the returned error.
+// Return without calling next to short-circuit.
+// The panic in the function and the interceptors is recovered as ErrCallFailure.
+type Interceptor func(ctx context.Context, inv *Invocation, next func(ctx context.Context) error) error
+
+// interceptorChain returns the new slice of the interceptors from the root to s.
+func (s *cliMap) interceptorChain() []Interceptor {
+	if s.parent == nil {
+		return append([]Interceptor{}, s.interceptors...)
+	}
+	// copy not to write into the backing array of the parent shared by the concurrent calls
+	return append(s.parent.interceptorChain(), s.interceptors...)
+}
+
+func (s *cliMap) Use(interceptor ...Interceptor) {
+	cliMu.Lock()
+	defer cliMu.Unlock()
+	s.interceptors = append(s.interceptors, interceptor...)
+}
@@ -0,0 +1,296 @@
+package lineedit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrInterrupted is returned by ReadLine if Ctrl-C is typed.
+var ErrInterrupted = errors.New("interrupted")
+
+// CompleteFunc returns the candidates for the last word of line.
+type CompleteFunc func(line string) []string
+
+// Editor reads lines with the history and the completion if the input is a terminal.
+type Editor struct {
+	in       *os.File
+	reader   *bufio.Reader
+	out      io.Writer
+	prompt   string
+	history  []string
+	complete CompleteFunc
+}
+
+// New returns a new Editor.
+// If in is not a terminal, reads lines without editing and does not print the prompt.
+func New(in io.Reader, out io.Writer, prompt string, complete CompleteFunc) *Editor {
+	e := &Editor{
+		reader:   bufio.NewReader(in),
+		out:      out,
+		prompt:   prompt,
+		complete: complete,
+	}
+	if f, ok := in.(*os.File); ok && isTerminal(f.Fd()) {
+		e.in = f
+	}
+	return e
+}
+
+// AddHistory appends line to the history.
+func (e *Editor) AddHistory(line string) {
+	if n := len(e.history); n > 0 && e.history[n-1] == line {
+		return
+	}
+	e.history = append(e.history, line)
+}
+
+// ReadLine reads a line without the trailing newline.
+// Returns io.EOF at the end of the input.
+func (e *Editor) ReadLine() (string, error) {
+	if e.in == nil {
+		return e.readLinePlain()
+	}
+	state, err := makeRaw(e.in.Fd())
+	if err != nil {
+		return e.readLinePlain()
+	}
+	defer restore(e.in.Fd(), state)
+	return e.readLineRaw(e.reader)
+}
+
+func (e *Editor) readLinePlain() (string, error) {
+	line, err := e.reader.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+type lineBuffer struct {
+	buf    []rune
+	cursor int
+}
+
+func (b *lineBuffer) String() string { return string(b.buf) }
+
+func (b *lineBuffer) set(v string) {
+	b.buf = []rune(v)
+	b.cursor = len(b.buf)
+}
+
+func (b *lineBuffer) insert(v string) {
+	rs := []rune(v)
+	b.buf = append(b.buf[:b.cursor], append(rs, b.buf[b.cursor:]...)...)
+	b.cursor += len(rs)
+}
+
+func (b *lineBuffer) backspace() {
+	if b.cursor == 0 {
+		return
+	}
+	b.buf = append(b.buf[:b.cursor-1], b.buf[b.cursor:]...)
+	b.cursor--
+}
+
+func (b *lineBuffer) delete() {
+	if b.cursor == len(b.buf) {
+		return
+	}
+	b.buf = append(b.buf[:b.cursor], b.buf[b.cursor+1:]...)
+}
+
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyTab       = 9
+	keyCtrlK     = 11
+	keyCtrlL     = 12
+	keyEnter     = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlU     = 21
+	keyEscape    = 27
+	keyBackspace = 127
+)
+
+// readLineRaw reads a line from the keys typed in raw mode.
+func (e *Editor) readLineRaw(in io.Reader) (string, error) {
+	reader, ok := in.(*bufio.Reader)
+	if !ok {
+		reader = bufio.NewReader(in)
+	}
+	var (
+		b            lineBuffer
+		historyIndex = len(e.history)
+		pending      string // the line being edited while browsing the history
+	)
+	moveHistory := func(d int) {
+		i := historyIndex + d
+		if i < 0 || i > len(e.history) {
+			return
+		}
+		if historyIndex == len(e.history) {
+			pending = b.String()
+		}
+		historyIndex = i
+		if i == len(e.history) {
+			b.set(pending)
+			return
+		}
+		b.set(e.history[i])
+	}
+
+	e.refresh(&b)
+	for {
+		r, _, err := reader.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		switch r {
+		case keyEnter, '\n':
+			fmt.Fprint(e.out, "\r\n")
+			return b.String(), nil
+		case keyCtrlC:
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", ErrInterrupted
+		case keyCtrlD:
+			if len(b.buf) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+			b.delete()
+		case keyBackspace, '\b':
+			b.backspace()
+		case keyCtrlA:
+			b.cursor = 0
+		case keyCtrlE:
+			b.cursor = len(b.buf)
+		case keyCtrlB:
+			if b.cursor > 0 {
+				b.cursor--
+			}
+		case keyCtrlF:
+			if b.cursor < len(b.buf) {
+				b.cursor++
+			}
+		case keyCtrlK:
+			b.buf = b.buf[:b.cursor]
+		case keyCtrlU:
+			b.buf = b.buf[b.cursor:]
+			b.cursor = 0
+		case keyCtrlL:
+			fmt.Fprint(e.out, "\x1b[H\x1b[2J")
+		case keyCtrlP:
+			moveHistory(-1)
+		case keyCtrlN:
+			moveHistory(1)
+		case keyTab:
+			e.completeWord(&b)
+		case keyEscape:
+			switch readEscape(reader) {
+			case 'A':
+				moveHistory(-1)
+			case 'B':
+				moveHistory(1)
+			case 'C':
+				if b.cursor < len(b.buf) {
+					b.cursor++
+				}
+			case 'D':
+				if b.cursor > 0 {
+					b.cursor--
+				}
+			case 'H':
+				b.cursor = 0
+			case 'F':
+				b.cursor = len(b.buf)
+			case '3': // delete
+				b.delete()
+			}
+		default:
+			if r >= ' ' && r != utf8.RuneError {
+				b.insert(string(r))
+			}
+		}
+		e.refresh(&b)
+	}
+}
+
+// readEscape reads the rest of the escape sequence and returns the final byte,
+// or the number for the sequences like ESC [ 3 ~.
+func readEscape(reader *bufio.Reader) byte {
+	c, err := reader.ReadByte()
+	if err != nil || (c != '[' && c != 'O') {
+		return 0
+	}
+	var last byte
+	for {
+		x, err := reader.ReadByte()
+		if err != nil {
+			return 0
+		}
+		if x >= '0' && x <= '9' {
+			last = x
+			continue
+		}
+		if x == '~' {
+			return last
+		}
+		return x
+	}
+}
+
+// refresh redraws the prompt and the line, and moves the cursor.
+func (e *Editor) refresh(b *lineBuffer) {
+	fmt.Fprintf(e.out, "\r%s%s\x1b[K", e.prompt, b.String())
+	if n := len(b.buf) - b.cursor; n > 0 {
+		fmt.Fprintf(e.out, "\x1b[%dD", n)
+	}
+}
+
+// completeWord completes the word before the cursor.
+func (e *Editor) completeWord(b *lineBuffer) {
+	if e.complete == nil {
+		return
+	}
+	var (
+		head       = string(b.buf[:b.cursor])
+		candidates = e.complete(head)
+	)
+	if len(candidates) == 0 {
+		return
+	}
+	word := head
+	if i := strings.LastIndexAny(head, " \t"); i >= 0 {
+		word = head[i+1:]
+	}
+
+	if len(candidates) == 1 {
+		b.insert(strings.TrimPrefix(candidates[0], word) + " ")
+		return
+	}
+	if p := commonPrefix(candidates); len(p) > len(word) && strings.HasPrefix(p, word) {
+		b.insert(strings.TrimPrefix(p, word))
+		return
+	}
+	fmt.Fprintf(e.out, "\r\n%s\r\n", strings.Join(candidates, "  "))
+}
+
+func commonPrefix(xs []string) string {
+	p := xs[0]
+	for _, x := range xs[1:] {
+		for !strings.HasPrefix(x, p) {
+			p = p[:len(p)-1]
+		}
+	}
+	return p
+}
@@ -0,0 +1,349 @@
+package lineedit
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadLineRaw(t *testing.T) {
+	const (
+		up    = "\x1b[A"
+		down  = "\x1b[B"
+		right = "\x1b[C"
+		left  = "\x1b[D"
+		home  = "\x1b[H"
+		end   = "\x1b[F"
+		del   = "\x1b[3~"
+	)
+	complete := func(line string) []string {
+		var (
+			word = line[strings.LastIndex(line, " ")+1:]
+			r    []string
+		)
+		for _, x := range []string{"greet", "grep", "help", "history"} {
+			if strings.HasPrefix(x, word) {
+				r = append(r, x)
+			}
+		}
+		return r
+	}
+
+	for _, tc := range []struct {
+		name    string
+		history []string
+		input   string
+		want    string
+		err     error
+		output  string // contained in the output
+	}{
+		{
+			name:  "enter",
+			input: "greet\r",
+			want:  "greet",
+		},
+		{
+			name:  "newline",
+			input: "greet\n",
+			want:  "greet",
+		},
+		{
+			name:  "multibyte",
+			input: "こんにちは\r",
+			want:  "こんにちは",
+		},
+		{
+			name:  "ignore control keys",
+			input: "a\x07\x1fb\r",
+			want:  "ab",
+		},
+		{
+			name:   "ctrl-c",
+			input:  "greet\x03rest\r",
+			err:    ErrInterrupted,
+			output: "^C\r\n",
+		},
+		{
+			name:  "ctrl-d on empty line",
+			input: "\x04",
+			err:   io.EOF,
+		},
+		{
+			name:  "ctrl-d deletes the rune under the cursor",
+			input: "abc\x01\x04\r",
+			want:  "bc",
+		},
+		{
+			name:  "eof without enter",
+			input: "greet",
+			err:   io.EOF,
+		},
+		{
+			name:  "backspace",
+			input: "abc\x7f\x7f\x7fd\x08e\r",
+			want:  "e",
+		},
+		{
+			name:  "backspace at the head",
+			input: "ab\x01\x7fc\r",
+			want:  "cab",
+		},
+		{
+			name:  "ctrl-a and ctrl-e",
+			input: "bc\x01a\x05d\r",
+			want:  "abcd",
+		},
+		{
+			name:  "ctrl-b and ctrl-f",
+			input: "ac\x02b\x06d\x06\x06e\r",
+			want:  "abcde",
+		},
+		{
+			name:  "ctrl-b at the head",
+			input: "b\x02\x02\x02a\r",
+			want:  "ab",
+		},
+		{
+			name:  "ctrl-k",
+			input: "abcd\x02\x02\x0b\r",
+			want:  "ab",
+		},
+		{
+			name:  "ctrl-u",
+			input: "abcd\x02\x02\x15x\r",
+			want:  "xcd",
+		},
+		{
+			name:   "ctrl-l",
+			input:  "ab\x0c\r",
+			want:   "ab",
+			output: "\x1b[H\x1b[2J",
+		},
+		{
+			name:  "arrows",
+			input: "ac" + left + "b" + right + right + "d\r",
+			want:  "abcd",
+		},
+		{
+			name:  "home end and delete",
+			input: "xbc" + home + del + "a" + end + "d\r",
+			want:  "abcd",
+		},
+		{
+			name:  "unknown escape",
+			input: "a\x1b[Zb\x1bxc\r",
+			want:  "abc",
+		},
+		{
+			name:    "up",
+			history: []string{"first", "second"},
+			input:   up + "\r",
+			want:    "second",
+		},
+		{
+			name:    "up past the oldest",
+			history: []string{"first", "second"},
+			input:   up + up + up + up + "\r",
+			want:    "first",
+		},
+		{
+			name:    "down past the newest restores the pending line",
+			history: []string{"first", "second"},
+			input:   "pen" + up + up + down + down + down + "d\r",
+			want:    "pend",
+		},
+		{
+			name:  "down without history",
+			input: "a" + down + "b\r",
+			want:  "ab",
+		},
+		{
+			name:    "ctrl-p and ctrl-n",
+			history: []string{"first", "second"},
+			input:   "\x10\x10\x0e\r",
+			want:    "second",
+		},
+		{
+			name:    "edit the history",
+			history: []string{"greet"},
+			input:   up + " -name\r",
+			want:    "greet -name",
+		},
+		{
+			name:  "tab with single candidate",
+			input: "he\tx\r",
+			want:  "help x",
+		},
+		{
+			name:  "tab with candidates having common prefix",
+			input: "g\t\r",
+			want:  "gre",
+		},
+		{
+			name:   "tab with candidates without common prefix",
+			input:  "h\t\r",
+			want:   "h",
+			output: "\r\nhelp  history\r\n",
+		},
+		{
+			name:  "tab without candidates",
+			input: "x\t\r",
+			want:  "x",
+		},
+		{
+			name:  "tab completes the last word",
+			input: "help gree\t\r",
+			want:  "help greet ",
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			e := New(strings.NewReader(""), &out, "> ", complete)
+			for _, x := range tc.history {
+				e.AddHistory(x)
+			}
+			got, err := e.readLineRaw(strings.NewReader(tc.input))
+			assert.ErrorIs(t, err, tc.err)
+			assert.Equal(t, tc.want, got)
+			assert.Contains(t, out.String(), tc.output)
+		})
+	}
+}
+
+func TestEditorReadLine(t *testing.T) {
+	var out bytes.Buffer
+	e := New(strings.NewReader("greet\r\n\nlast"), &out, "> ", nil)
+	for _, want := range []string{"greet", "", "last"} {
+		got, err := e.ReadLine()
+		assert.Nil(t, err)
+		assert.Equal(t, want, got)
+	}
+	_, err := e.ReadLine()
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, "", out.String(), "no prompt if not a terminal")
+}
+
+func TestEditorAddHistory(t *testing.T) {
+	e := New(strings.NewReader(""), io.Discard, "> ", nil)
+	for _, x := range []string{"a", "b", "b", "a"} {
+		e.AddHistory(x)
+	}
+	assert.Equal(t, []string{"a", "b", "a"}, e.history)
+}
+
+func TestCompleteWord(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		line       string
+		cursor     int
+		candidates []string
+		want       string
+		wantCursor int
+		output     string
+	}{
+		{
+			name:       "no candidates",
+			line:       "gr",
+			cursor:     2,
+			want:       "gr",
+			wantCursor: 2,
+		},
+		{
+			name:       "single",
+			line:       "gr",
+			cursor:     2,
+			candidates: []string{"greet"},
+			want:       "greet ",
+			wantCursor: 6,
+		},
+		{
+			name:       "common prefix",
+			line:       "g",
+			cursor:     1,
+			candidates: []string{"greet", "grep"},
+			want:       "gre",
+			wantCursor: 3,
+		},
+		{
+			name:       "list",
+			line:       "gre",
+			cursor:     3,
+			candidates: []string{"greet", "grep"},
+			want:       "gre",
+			wantCursor: 3,
+			output:     "\r\ngreet  grep\r\n",
+		},
+		{
+			name:       "before the cursor",
+			line:       "help gr x",
+			cursor:     7,
+			candidates: []string{"greet"},
+			want:       "help greet  x",
+			wantCursor: 11,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				out  bytes.Buffer
+				head string
+			)
+			e := New(strings.NewReader(""), &out, "> ", func(line string) []string {
+				head = line
+				return tc.candidates
+			})
+			b := lineBuffer{
+				buf:    []rune(tc.line),
+				cursor: tc.cursor,
+			}
+			e.completeWord(&b)
+			assert.Equal(t, string([]rune(tc.line)[:tc.cursor]), head)
+			assert.Equal(t, tc.want, b.String())
+			assert.Equal(t, tc.wantCursor, b.cursor)
+			assert.Equal(t, tc.output, out.String())
+		})
+	}
+}
+
+func TestCommonPrefix(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		xs   []string
+		want string
+	}{
+		{
+			name: "single",
+			xs:   []string{"greet"},
+			want: "greet",
+		},
+		{
+			name: "prefix",
+			xs:   []string{"greet", "grep", "green"},
+			want: "gre",
+		},
+		{
+			name: "one is the prefix",
+			xs:   []string{"help", "he"},
+			want: "he",
+		},
+		{
+			name: "none",
+			xs:   []string{"greet", "help"},
+			want: "",
+		},
+		{
+			name: "empty",
+			xs:   []string{"", "help"},
+			want: "",
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, commonPrefix(tc.xs))
+		})
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package lineedit
+
+import "errors"
+
+type termState struct{}
+
+func isTerminal(uintptr) bool { return false }
+
+func makeRaw(uintptr) (*termState, error) { return nil, errors.New("raw mode is not supported") }
+
+func restore(uintptr, *termState) error { return nil }
@@ -0,0 +1,81 @@
+package shellwords
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrUnterminatedQuote  = errors.New("unterminated quote")
+	ErrUnterminatedEscape = errors.New("unterminated escape")
+)
+
+// Split splits line into words like a shell.
+// Words are separated by spaces and tabs.
+// Single quotes preserve the enclosed characters,
+// double quotes preserve them except \ before ", \, $ and `,
+// \ outside quotes preserves the next character.
+func Split(line string) ([]string, error) {
+	var (
+		words   = []string{}
+		word    strings.Builder
+		inWord  bool
+		quote   rune
+		escaped bool
+	)
+	for _, c := range line {
+		switch {
+		case escaped:
+			if quote == '"' && !strings.ContainsRune("\"\\$`\n", c) {
+				word.WriteRune('\\')
+			}
+			if c != '\n' {
+				word.WriteRune(c)
+			}
+			escaped = false
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+				continue
+			}
+			word.WriteRune(c)
+		case quote == '"':
+			switch c {
+			case '"':
+				quote = 0
+			case '\\':
+				escaped = true
+			default:
+				word.WriteRune(c)
+			}
+		default:
+			switch c {
+			case ' ', '\t', '\n':
+				if inWord {
+					words = append(words, word.String())
+					word.Reset()
+					inWord = false
+				}
+				continue
+			case '\'', '"':
+				quote = c
+			case '\\':
+				escaped = true
+			default:
+				word.WriteRune(c)
+			}
+			inWord = true
+		}
+	}
+
+	switch {
+	case escaped:
+		return nil, ErrUnterminatedEscape
+	case quote != 0:
+		return nil, ErrUnterminatedQuote
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
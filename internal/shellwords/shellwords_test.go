@@ -0,0 +1,120 @@
+package shellwords_test
+
+import (
+	"testing"
+
+	"github.com/berquerant/fcli/internal/shellwords"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplit(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		line string
+		want []string
+		err  error
+	}{
+		{
+			name: "empty",
+			line: "",
+			want: []string{},
+		},
+		{
+			name: "spaces",
+			line: " \t ",
+			want: []string{},
+		},
+		{
+			name: "words",
+			line: "  greet\t-name  world \n",
+			want: []string{"greet", "-name", "world"},
+		},
+		{
+			name: "single quotes",
+			line: `greet -name 'new  world' 'a\b"c$d'`,
+			want: []string{"greet", "-name", "new  world", `a\b"c$d`},
+		},
+		{
+			name: "double quotes",
+			line: `greet -name "new  world" "it's"`,
+			want: []string{"greet", "-name", "new  world", "it's"},
+		},
+		{
+			name: "empty quotes",
+			line: `a '' "" b`,
+			want: []string{"a", "", "", "b"},
+		},
+		{
+			name: "quotes in word",
+			line: `-name='new world'x"y z"`,
+			want: []string{"-name=new worldxy z"},
+		},
+		{
+			name: "escape in double quotes",
+			line: `"a\"b" "c\\d" "e\$f" "g\` + "`" + `h"`,
+			want: []string{`a"b`, `c\d`, `e$f`, "g`h"},
+		},
+		{
+			name: "backslash kept in double quotes",
+			line: `"a\nb" "c\'d"`,
+			want: []string{`a\nb`, `c\'d`},
+		},
+		{
+			name: "line continuation in double quotes",
+			line: "\"a\\\nb\"",
+			want: []string{"ab"},
+		},
+		{
+			name: "escape outside quotes",
+			line: `new\ world \'a\' \\ \"`,
+			want: []string{"new world", "'a'", `\`, `"`},
+		},
+		{
+			name: "line continuation",
+			line: "a\\\nb c",
+			want: []string{"ab", "c"},
+		},
+		{
+			name: "single quote after escape",
+			line: `it\''s me'`,
+			want: []string{"it's me"},
+		},
+		{
+			name: "multibyte",
+			line: "こんにちは '世界'",
+			want: []string{"こんにちは", "世界"},
+		},
+		{
+			name: "unterminated single quote",
+			line: `greet 'world`,
+			err:  shellwords.ErrUnterminatedQuote,
+		},
+		{
+			name: "unterminated double quote",
+			line: `greet "world`,
+			err:  shellwords.ErrUnterminatedQuote,
+		},
+		{
+			name: "unterminated double quote after escape",
+			line: `"a\"`,
+			err:  shellwords.ErrUnterminatedQuote,
+		},
+		{
+			name: "unterminated escape",
+			line: `greet \`,
+			err:  shellwords.ErrUnterminatedEscape,
+		},
+		{
+			name: "unterminated escape in double quotes",
+			line: `"greet \`,
+			err:  shellwords.ErrUnterminatedEscape,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := shellwords.Split(tc.line)
+			assert.ErrorIs(t, err, tc.err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package suggest
+
+import "sort"
+
+// Distance returns the Levenshtein distance between a and b.
+func Distance(a, b string) int {
+	var (
+		x    = []rune(a)
+		y    = []rune(b)
+		prev = make([]int, len(y)+1)
+		curr = make([]int, len(y)+1)
+	)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(x); i++ {
+		curr[0] = i
+		for j := 1; j <= len(y); j++ {
+			cost := 1
+			if x[i-1] == y[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(y)]
+}
+
+func min(x int, xs ...int) int {
+	for _, v := range xs {
+		if v < x {
+			x = v
+		}
+	}
+	return x
+}
+
+// MaxSuggestions is the maximum number of the results of Rank.
+const MaxSuggestions = 3
+
+// Rank returns the candidates close to target, the closest first.
+// The candidates too far from target are dropped.
+func Rank(target string, candidates []string) []string {
+	type scored struct {
+		name     string
+		distance int
+	}
+
+	var (
+		threshold = len([]rune(target)) / 2
+		xs        = []scored{}
+		seen      = map[string]bool{}
+	)
+	if threshold < 1 {
+		threshold = 1
+	}
+	for _, c := range candidates {
+		if c == target || seen[c] {
+			continue
+		}
+		seen[c] = true
+		if d := Distance(target, c); d <= threshold {
+			xs = append(xs, scored{
+				name:     c,
+				distance: d,
+			})
+		}
+	}
+	sort.Slice(xs, func(i, j int) bool {
+		if xs[i].distance == xs[j].distance {
+			return xs[i].name < xs[j].name
+		}
+		return xs[i].distance < xs[j].distance
+	})
+
+	if len(xs) > MaxSuggestions {
+		xs = xs[:MaxSuggestions]
+	}
+	r := make([]string, len(xs))
+	for i, x := range xs {
+		r[i] = x.name
+	}
+	return r
+}
@@ -0,0 +1,94 @@
+package suggest_test
+
+import (
+	"testing"
+
+	"github.com/berquerant/fcli/internal/suggest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistance(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{a: "", b: "", want: 0},
+		{a: "", b: "abc", want: 3},
+		{a: "abc", b: "", want: 3},
+		{a: "greet", b: "greet", want: 0},
+		{a: "greet", b: "gret", want: 1},
+		{a: "greet", b: "greeet", want: 1},
+		{a: "greet", b: "grees", want: 1},
+		{a: "kitten", b: "sitting", want: 3},
+		{a: "こんにちは", b: "こんばんは", want: 2},
+	} {
+		tc := tc
+		t.Run(tc.a+"_"+tc.b, func(t *testing.T) {
+			assert.Equal(t, tc.want, suggest.Distance(tc.a, tc.b))
+			assert.Equal(t, tc.want, suggest.Distance(tc.b, tc.a))
+		})
+	}
+}
+
+func TestRank(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		target     string
+		candidates []string
+		want       []string
+	}{
+		{
+			name:       "no candidates",
+			target:     "greet",
+			candidates: nil,
+			want:       []string{},
+		},
+		{
+			name:       "closest first",
+			target:     "stat",
+			candidates: []string{"start", "status", "stop"},
+			want:       []string{"start", "status", "stop"},
+		},
+		{
+			name:       "ties in alphabetical order",
+			target:     "ab",
+			candidates: []string{"bb", "ac", "aa", "cb"},
+			want:       []string{"aa", "ac", "bb"},
+		},
+		{
+			name:       "at most MaxSuggestions",
+			target:     "abcd",
+			candidates: []string{"abce", "abcf", "abcg", "abch", "abc"},
+			want:       []string{"abc", "abce", "abcf"},
+		},
+		{
+			name:       "threshold is half of the target",
+			target:     "greet",
+			candidates: []string{"gr", "grt", "great", "green"},
+			want:       []string{"great", "green", "grt"},
+		},
+		{
+			name:       "threshold is at least 1",
+			target:     "a",
+			candidates: []string{"b", "ab", "bc"},
+			want:       []string{"ab", "b"},
+		},
+		{
+			name:       "exclude target and duplicates",
+			target:     "greet",
+			candidates: []string{"greet", "gret", "gret"},
+			want:       []string{"gret"},
+		},
+		{
+			name:       "too far",
+			target:     "deploy",
+			candidates: []string{"greet", "status"},
+			want:       []string{},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, suggest.Rank(tc.target, tc.candidates))
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package fcli
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ParamInfo is the metadata of the parameter of the function.
+type ParamInfo struct {
+	// Name is the flag name, the parameter name.
+	Name string
+	// Type is the Go type of the parameter.
+	Type reflect.Type
+	// Kind is the kind of the flag like int, bool, string and custom, see NewFlagFactory.
+	Kind string
+	// Default is the default value of the flag as text.
+	Default string
+	// Usage is the usage of the flag.
+	Usage string
+}
+
+// CommandInfo is the metadata of the command.
+type CommandInfo struct {
+	// Path is the names of the CLI, the groups and the command separated by space.
+	Path string
+	// Name is the command name.
+	Name string
+	// Aliases is the aliases of the command.
+	Aliases []string
+	// Category is the category of the command.
+	Category string
+	// Hidden is true if the command is hidden from usage.
+	Hidden bool
+	// Deprecated is the deprecation message, empty if not deprecated.
+	Deprecated string
+	// Doc is the doc comment of the function.
+	Doc string
+	// Func is the name and the location of the function.
+	Func *FuncName
+	// Params is the parameters of the function except context.Context.
+	Params []ParamInfo
+}
+
+func (s *targetFunction) FuncName() *FuncName { return s.fname }
+
+func (s *targetFunction) Params() []ParamInfo {
+	params := make([]ParamInfo, len(s.flags))
+	for i, f := range s.flags {
+		p := ParamInfo{
+			Name: f.Name(),
+			Type: s.flagTypes[i],
+			Kind: flagKind(f),
+		}
+		if x := s.flagSet.Lookup(f.Name()); x != nil {
+			p.Default = x.DefValue
+			p.Usage = x.Usage
+		}
+		params[i] = p
+	}
+	return params
+}
+
+// flagKind returns the kind of the flag by the type name, like int for IntFlag.
+func flagKind(f Flag) string {
+	t := reflect.TypeOf(f)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return strings.ToLower(strings.TrimSuffix(t.Name(), "Flag"))
+}
+
+func (s *targetFunction) info(path string) CommandInfo {
+	return CommandInfo{
+		Path:       path,
+		Name:       s.Name(),
+		Aliases:    append([]string{}, s.Aliases()...),
+		Category:   s.Category(),
+		Hidden:     s.Hidden(),
+		Deprecated: s.Deprecated(),
+		Doc:        s.Doc(),
+		Func:       s.FuncName(),
+		Params:     s.Params(),
+	}
+}
+
+func (s *cliMap) Commands() []CommandInfo {
+	cliMu.RLock()
+	defer cliMu.RUnlock()
+	return s.commandInfos()
+}
+
+// commandInfos returns the metadata of the commands of s and the groups in usage order.
+// Requires cliMu.
+func (s *cliMap) commandInfos() []CommandInfo {
+	infos := []CommandInfo{}
+	for _, name := range s.sortedNames() {
+		if g, ok := s.groups[name]; ok {
+			infos = append(infos, g.commandInfos()...)
+			continue
+		}
+		infos = append(infos, s.commands[name].info(fmt.Sprintf("%s %s", s.path(), name)))
+	}
+	return infos
+}
@@ -0,0 +1,74 @@
+package fcli_test
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/berquerant/fcli"
+	"github.com/stretchr/testify/assert"
+)
+
+// introspectTestMigrate migrates the database.
+func introspectTestMigrate(ctx context.Context, version int, dryRun bool, tables *stringList) error {
+	return nil
+}
+
+func introspectTestStatus() {}
+
+func TestCLICommands(t *testing.T) {
+	cli := fcli.NewCLI("tool")
+	cli.Order(fcli.OrderRegistration)
+	assert.Nil(t, cli.Add(introspectTestStatus,
+		fcli.WithCommandName("status"),
+		fcli.WithAliases([]string{"st"}),
+		fcli.WithHidden(true),
+	))
+	assert.Nil(t, cli.Group("db").Add(introspectTestMigrate,
+		fcli.WithCommandName("migrate"),
+		fcli.WithCategory("Database"),
+		fcli.WithDeprecated("use up"),
+		fcli.WithDeprecatedFlags(map[string]string{"dryRun": "always dry"}),
+	))
+
+	got := cli.Commands()
+	if !assert.Equal(t, 2, len(got)) {
+		return
+	}
+
+	status := got[0]
+	assert.Equal(t, "tool status", status.Path)
+	assert.Equal(t, "status", status.Name)
+	assert.Equal(t, []string{"st"}, status.Aliases)
+	assert.True(t, status.Hidden)
+	assert.Equal(t, 0, len(status.Params))
+
+	migrate := got[1]
+	assert.Equal(t, "tool db migrate", migrate.Path)
+	assert.Equal(t, "Database", migrate.Category)
+	assert.Equal(t, "use up", migrate.Deprecated)
+	assert.Equal(t, "introspectTestMigrate migrates the database.\n", migrate.Doc)
+	assert.Equal(t, "introspect_test.go", filepath.Base(migrate.Func.File()))
+	assert.Equal(t, "introspectTestMigrate", migrate.Func.String())
+	assert.Equal(t, []fcli.ParamInfo{
+		{
+			Name:    "version",
+			Type:    reflect.TypeOf(0),
+			Kind:    "int",
+			Default: "0",
+		},
+		{
+			Name:    "dryRun",
+			Type:    reflect.TypeOf(false),
+			Kind:    "bool",
+			Default: "false",
+			Usage:   "Deprecated: always dry",
+		},
+		{
+			Name: "tables",
+			Type: reflect.TypeOf(&stringList{}),
+			Kind: "custom",
+		},
+	}, migrate.Params)
+}
@@ -0,0 +1,101 @@
+package fcli
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/berquerant/fcli/internal/logger"
+)
+
+func (s *cliMap) AddMethods(obj any, names ...string) error {
+	cliMu.RLock()
+	opt := s.commandOptions(nil)
+	cliMu.RUnlock()
+	ts, err := newMethodTargetFunctions(obj, names, opt)
+	if err != nil {
+		return err
+	}
+	cliMu.Lock()
+	defer cliMu.Unlock()
+	// check all before registering not to add a part of the methods
+	seen := map[string]bool{}
+	for _, t := range ts {
+		if seen[t.Name()] {
+			return fmt.Errorf("%w %s %s given twice", ErrCLIDuplicateCommand, s.path(), t.Name())
+		}
+		seen[t.Name()] = true
+		if err := s.checkCommand(t); err != nil {
+			return err
+		}
+	}
+	for _, t := range ts {
+		s.registerCommand(t)
+	}
+	return nil
+}
+
+// newMethodTargetFunctions builds the target functions of the methods of obj named names, all exported methods if names is empty.
+// If names is empty, the methods that cannot be the commands like String() string are skipped.
+func newMethodTargetFunctions(obj any, names []string, opt []Option) ([]*targetFunction, error) {
+	if obj == nil {
+		return nil, fmt.Errorf("%w nil", ErrCLIMethodNotFound)
+	}
+	var (
+		v       = reflect.ValueOf(obj)
+		t       = v.Type()
+		methods []reflect.Method
+	)
+	if len(names) == 0 {
+		for i := 0; i < t.NumMethod(); i++ {
+			methods = append(methods, t.Method(i))
+		}
+	}
+	for _, name := range names {
+		m, ok := t.MethodByName(name)
+		if !ok {
+			return nil, fmt.Errorf("%w %s of %v", ErrCLIMethodNotFound, name, t)
+		}
+		methods = append(methods, m)
+	}
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("%w %v has no exported methods", ErrCLIMethodNotFound, t)
+	}
+
+	ts := []*targetFunction{}
+	for _, m := range methods {
+		tf, err := newMethodTargetFunction(v, m, opt)
+		if err != nil {
+			if len(names) == 0 {
+				logger.Debug("Skip method %s of %v: %v", m.Name, t, err)
+				continue
+			}
+			return nil, err
+		}
+		ts = append(ts, tf)
+	}
+	if len(ts) == 0 {
+		return nil, fmt.Errorf("%w %v has no methods available as commands", ErrCLIMethodNotFound, t)
+	}
+	return ts, nil
+}
+
+func newMethodTargetFunction(v reflect.Value, m reflect.Method, opt []Option) (*targetFunction, error) {
+	fname, err := methodFuncName(v.Type(), m)
+	if err != nil {
+		return nil, fmt.Errorf("%w cannot get method name %s of %v", ErrBadTargetFunction, m.Name, v.Type())
+	}
+	// the method value binds the receiver, so the parameters are the same as the method decl
+	return newTargetFunctionWithName(v.Method(m.Index).Interface(), fname, opt...)
+}
+
+// methodFuncName returns the name and the location of the method declaration.
+// The method of the pointer type whose receiver is the value is the wrapper generated by the compiler,
+// so find it from the value type.
+func methodFuncName(t reflect.Type, m reflect.Method) (*FuncName, error) {
+	if t.Kind() == reflect.Pointer {
+		if x, ok := t.Elem().MethodByName(m.Name); ok {
+			m = x
+		}
+	}
+	return GetFuncName(m.Func.Interface())
+}
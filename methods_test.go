@@ -0,0 +1,116 @@
+package fcli_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/berquerant/fcli"
+	"github.com/stretchr/testify/assert"
+)
+
+type methodsTestService struct {
+	prefix   string
+	recorder []string
+}
+
+// Greet greets the user.
+func (s *methodsTestService) Greet(ctx context.Context, name string, times int) {
+	for i := 0; i < times; i++ {
+		s.recorder = append(s.recorder, fmt.Sprintf("%s %s", s.prefix, name))
+	}
+}
+
+// Count returns the number of the greetings as the exit status.
+func (s methodsTestService) Count() (int, error) {
+	return len(s.recorder), nil
+}
+
+// Reset clears the greetings.
+func (s *methodsTestService) Reset() error {
+	s.recorder = nil
+	return nil
+}
+
+// String is not a command.
+func (s *methodsTestService) String() string { return s.prefix }
+
+func TestCLIAddMethods(t *testing.T) {
+	t.Run("all methods", func(t *testing.T) {
+		svc := &methodsTestService{prefix: "hello"}
+		cli := fcli.NewCLI("tool")
+		cli.OnError(func(error) int { return fcli.Cerror })
+		assert.Nil(t, cli.AddMethods(svc))
+
+		got := cli.Commands()
+		if !assert.Equal(t, 3, len(got)) {
+			return
+		}
+		assert.Equal(t, "Count", got[0].Name)
+		assert.Equal(t, "Count returns the number of the greetings as the exit status.\n", got[0].Doc)
+		assert.Equal(t, "Greet", got[1].Name)
+		assert.Equal(t, "Greet greets the user.\n", got[1].Doc)
+		assert.Equal(t, "methods_test.go", filepath.Base(got[1].Func.File()))
+		if assert.Equal(t, 2, len(got[1].Params)) {
+			assert.Equal(t, "name", got[1].Params[0].Name)
+			assert.Equal(t, "times", got[1].Params[1].Name)
+		}
+		assert.Equal(t, "Reset", got[2].Name)
+
+		assert.Nil(t, cli.Start("Greet", "-name", "alice", "-times", "2"))
+		assert.Equal(t, []string{"hello alice", "hello alice"}, svc.recorder)
+		assert.Nil(t, cli.Start("Reset"))
+		assert.Equal(t, 0, len(svc.recorder))
+	})
+
+	t.Run("selected methods", func(t *testing.T) {
+		svc := &methodsTestService{prefix: "hi"}
+		cli := fcli.NewCLI("tool")
+		assert.Nil(t, cli.AddMethods(svc, "Greet"))
+		got := cli.Commands()
+		if assert.Equal(t, 1, len(got)) {
+			assert.Equal(t, "Greet", got[0].Name)
+		}
+	})
+
+	t.Run("value receiver", func(t *testing.T) {
+		cli := fcli.NewCLI("tool")
+		assert.Nil(t, cli.AddMethods(methodsTestService{}))
+		got := cli.Commands()
+		if assert.Equal(t, 1, len(got)) {
+			assert.Equal(t, "Count", got[0].Name)
+		}
+	})
+
+	t.Run("method not found", func(t *testing.T) {
+		cli := fcli.NewCLI("tool")
+		assert.ErrorIs(t, cli.AddMethods(&methodsTestService{}, "Greet", "reset"), fcli.ErrCLIMethodNotFound)
+		assert.Equal(t, 0, len(cli.Commands()))
+	})
+
+	t.Run("no methods", func(t *testing.T) {
+		cli := fcli.NewCLI("tool")
+		assert.ErrorIs(t, cli.AddMethods(struct{}{}), fcli.ErrCLIMethodNotFound)
+		assert.ErrorIs(t, cli.AddMethods(nil), fcli.ErrCLIMethodNotFound)
+	})
+
+	t.Run("unsupported method given", func(t *testing.T) {
+		cli := fcli.NewCLI("tool")
+		assert.ErrorIs(t, cli.AddMethods(&methodsTestService{}, "Greet", "String"), fcli.ErrBadTargetFunction)
+		assert.Equal(t, 0, len(cli.Commands()))
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		cli := fcli.NewCLI("tool")
+		assert.Nil(t, cli.AddMethods(&methodsTestService{}, "Greet"))
+		assert.ErrorIs(t, cli.AddMethods(&methodsTestService{}, "Greet"), fcli.ErrCLIDuplicateCommand)
+		// no methods are added if any fails
+		assert.ErrorIs(t, cli.AddMethods(&methodsTestService{}), fcli.ErrCLIDuplicateCommand)
+		assert.ErrorIs(t, cli.AddMethods(&methodsTestService{}, "Reset", "Reset"), fcli.ErrCLIDuplicateCommand)
+		got := cli.Commands()
+		if assert.Equal(t, 1, len(got)) {
+			assert.Equal(t, "Greet", got[0].Name)
+		}
+	})
+}
@@ -0,0 +1,172 @@
+package fcli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/berquerant/fcli/internal/logger"
+)
+
+var (
+	ErrCLIPluginFailure = errors.New("plugin failure")
+)
+
+// PluginError is the error returned if the plugin failed.
+type PluginError struct {
+	// Name is the plugin name, the command name.
+	Name string
+	// File is the path of the executable.
+	File string
+	// Code is the exit status of the plugin, -1 if not started or killed.
+	Code int
+	// Err is the error of the execution.
+	Err error
+}
+
+func (e *PluginError) Error() string {
+	return fmt.Sprintf("%v %s %s %v", ErrCLIPluginFailure, e.Name, e.File, e.Err)
+}
+
+func (e *PluginError) Unwrap() error { return e.Err }
+func (e *PluginError) Is(target error) bool {
+	return target == ErrCLIPluginFailure
+}
+
+// ExitCode returns the exit status of the plugin, or ExitCodeError if the plugin did not exit.
+func (e *PluginError) ExitCode() int {
+	if e.Code < 0 {
+		return ExitCodeError
+	}
+	return e.Code
+}
+
+func (s *cliMap) Plugins(enabled bool) {
+	cliMu.Lock()
+	defer cliMu.Unlock()
+	s.plugins = enabled
+}
+
+func (s *cliMap) pluginsEnabled() bool {
+	for x := s; x != nil; x = x.parent {
+		if x.plugins {
+			return true
+		}
+	}
+	return false
+}
+
+// pluginPrefix returns the prefix of the executables of the plugins, like tool-db- for the group db.
+func (s *cliMap) pluginPrefix() string {
+	return strings.ReplaceAll(s.path(), " ", "-") + "-"
+}
+
+// lookupPlugin returns the executable of the plugin named name.
+func (s *cliMap) lookupPlugin(name string) (string, bool) {
+	if !s.pluginsEnabled() || name == "" || strings.ContainsAny(name, `/\`) {
+		return "", false
+	}
+	file, err := exec.LookPath(s.pluginPrefix() + name)
+	if err != nil {
+		return "", false
+	}
+	return file, true
+}
+
+// pluginNames returns the names of the plugins found in PATH except the commands and the groups.
+func (s *cliMap) pluginNames() []string {
+	if !s.pluginsEnabled() {
+		return nil
+	}
+	var (
+		prefix = s.pluginPrefix()
+		found  = map[string]bool{}
+	)
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, x := range entries {
+			name, ok := pluginName(prefix, x)
+			if !ok {
+				continue
+			}
+			if _, ok := s.commands[name]; ok {
+				continue
+			}
+			if s.isGroupPlugin(name) {
+				continue
+			}
+			found[name] = true
+		}
+	}
+	names := make([]string, 0, len(found))
+	for k := range found {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isGroupPlugin returns true if name is the group or the plugin of the group, like db-dump for the group db.
+func (s *cliMap) isGroupPlugin(name string) bool {
+	for g := range s.groups {
+		if name == g || strings.HasPrefix(name, g+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+func pluginName(prefix string, entry os.DirEntry) (string, bool) {
+	name := entry.Name()
+	if runtime.GOOS == "windows" {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	if !strings.HasPrefix(name, prefix) || len(name) == len(prefix) || entry.IsDir() {
+		return "", false
+	}
+	info, err := entry.Info()
+	if err != nil {
+		return "", false
+	}
+	if runtime.GOOS != "windows" && info.Mode()&0o111 == 0 {
+		return "", false
+	}
+	return strings.TrimPrefix(name, prefix), true
+}
+
+// callPlugin executes the plugin with args, passing through stdio.
+func callPlugin(ctx context.Context, name, file string, args []string) error {
+	logger.Debug("Call plugin %s %s %v", name, file, args)
+	var (
+		cmd = exec.CommandContext(ctx, file, args...)
+		st  = streamsFrom(ctx)
+	)
+	cmd.Stdin = st.stdin
+	cmd.Stdout = st.stdout
+	cmd.Stderr = st.stderr
+	cmd.Env = environ(ctx)
+	err := cmd.Run()
+	if err == nil {
+		return nil
+	}
+	code := -1
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		code = exitErr.ExitCode()
+	}
+	return &PluginError{
+		Name: name,
+		File: file,
+		Code: code,
+		Err:  err,
+	}
+}
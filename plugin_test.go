@@ -0,0 +1,87 @@
+//go:build linux || darwin
+
+package fcli_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/berquerant/fcli"
+	"github.com/stretchr/testify/assert"
+)
+
+func writePluginForTest(t *testing.T, dir, name, script string) {
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script+"\n"), 0o755))
+}
+
+func TestCLIPlugins(t *testing.T) {
+	dir := t.TempDir()
+	writePluginForTest(t, dir, "tool-hello", `echo "hello $*"`)
+	writePluginForTest(t, dir, "tool-fail", `echo "failed" >&2; exit 7`)
+	writePluginForTest(t, dir, "tool-db-dump", `echo "dump $1"`)
+	writePluginForTest(t, dir, "tool-status", `echo "plugin status"`)
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "tool-data"), []byte("not executable"), 0o644))
+	t.Setenv("PATH", dir)
+
+	newCLI := func(t *testing.T, enabled bool) fcli.CLI {
+		cli := fcli.NewCLI("tool")
+		cli.Plugins(enabled)
+		assert.Nil(t, cli.Add(cliTestStatus, fcli.WithCommandName("status")))
+		_ = cli.Group("db")
+		return cli
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		cli := newCLI(t, false)
+		cli.OnError(func(error) int { return fcli.Cerror })
+		assert.ErrorIs(t, cli.Start("hello"), fcli.ErrCLICommandNotFound)
+	})
+
+	t.Run("exec", func(t *testing.T) {
+		cli := newCLI(t, true)
+		got := captureStdout(t, func() {
+			assert.Equal(t, fcli.ExitCodeSuccess, cli.Run("hello", "-x", "world"))
+		})
+		assert.Equal(t, "hello -x world\n", got)
+	})
+
+	t.Run("group", func(t *testing.T) {
+		cli := newCLI(t, true)
+		got := captureStdout(t, func() {
+			assert.Equal(t, fcli.ExitCodeSuccess, cli.Run("db", "dump", "users"))
+		})
+		assert.Equal(t, "dump users\n", got)
+	})
+
+	t.Run("exit code", func(t *testing.T) {
+		cli := newCLI(t, true)
+		var err error
+		got := captureStderr(t, func() {
+			err = cli.Start("fail")
+		})
+		// the usage is not printed
+		assert.Equal(t, "failed\n", got)
+		assert.ErrorIs(t, err, fcli.ErrCLIPluginFailure)
+		assert.Equal(t, 7, fcli.ExitCode(err))
+	})
+
+	t.Run("usage", func(t *testing.T) {
+		cli := newCLI(t, true)
+		got := captureStdout(t, func() {
+			assert.Nil(t, cli.Start("help"))
+		})
+		assert.True(t, strings.Contains(got, "\nPlugins:\n  fail\n  hello\n\n"), got)
+
+		got = captureStdout(t, func() {
+			assert.Nil(t, cli.Start("help", "db"))
+		})
+		assert.True(t, strings.Contains(got, "\nPlugins:\n  dump\n\n"), got)
+
+		got = captureStdout(t, func() {
+			assert.Nil(t, cli.Start("__complete", "h"))
+		})
+		assert.Equal(t, "hello\nhelp\n", got)
+	})
+}
@@ -34,12 +34,14 @@ func (s *FuncName) File() string     { return s.file }
 func (s *FuncName) Line() int        { return s.line }
 func (s *FuncName) String() string {
 	ss := strings.Split(s.fullname, ".")
-	return ss[len(ss)-1]
+	// trim the suffix of the method value like pkg.(*T).Method-fm
+	return strings.TrimSuffix(ss[len(ss)-1], "-fm")
 }
 
 // GetFuncName returns the function name.
 // Returns ErrNotFunction if f is not a function.
-// Note: this is not for method, literal.
+// Note: this is not for method value, literal.
+// For methods, pass the method expression like (*T).Method.
 func GetFuncName(f any) (*FuncName, error) {
 	if reflect.TypeOf(f).Kind() != reflect.Func {
 		return nil, ErrNotFunction
@@ -131,12 +133,22 @@ func (s *funcDeclCutter) CutFuncDecl() (string, error) {
 	return strings.Join(append(doc, body...), "\n"), nil
 }
 
+// findFuncHead finds the func decl head and moves the line to it.
+// The line may point to the body, e.g. the first statement of the function that has no calls.
 func (s *funcDeclCutter) findFuncHead() (string, bool) {
-	pivot, ok := s.file.Line(s.line)
-	if !ok || !strings.HasPrefix(pivot, "func ") {
-		return "", false
+	for i := s.line; ; i-- {
+		line, ok := s.file.Line(i)
+		if !ok {
+			return "", false
+		}
+		if strings.HasPrefix(line, "func ") {
+			s.line = i
+			return line, true
+		}
+		if line != "" && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			return "", false
+		}
 	}
-	return pivot, true
 }
 
 func (s *funcDeclCutter) findFuncBody() ([]string, bool) {
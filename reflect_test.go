@@ -99,6 +99,37 @@ func g() {}`,
   println(name)
 }`,
 		},
+		{
+			name: "line in body",
+			file: `package tmp
+func g() {}
+// f is leaf.
+func f(x int) int {
+	if x > 0 {
+
+		return x
+	}
+	return 0
+}`,
+			line: 5,
+			want: `// f is leaf.
+func f(x int) int {
+	if x > 0 {
+
+		return x
+	}
+	return 0
+}`,
+		},
+		{
+			name: "line after func",
+			file: `package tmp
+func f() {
+}
+var x = 1`,
+			line: 4,
+			err:  fcli.ErrCannotCutFuncDecl,
+		},
 		{
 			name: "inline with doc",
 			file: `package tmp
@@ -0,0 +1,137 @@
+package fcli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/berquerant/fcli/internal/lineedit"
+	"github.com/berquerant/fcli/internal/logger"
+	"github.com/berquerant/fcli/internal/shellwords"
+)
+
+var (
+	ErrCLIREPLFailure = errors.New("cli repl failure")
+)
+
+var replExitCommandNames = []string{"exit", "quit"}
+
+func isREPLExitCommand(name string) bool {
+	for _, x := range replExitCommandNames {
+		if x == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *cliMap) REPL(ctx context.Context, historyFile string) error {
+	history, err := readREPLHistory(historyFile)
+	if err != nil {
+		return fmt.Errorf("%w read history %v", ErrCLIREPLFailure, err)
+	}
+	cliMu.RLock()
+	var (
+		path = s.path()
+		st   = s.streams(ctx)
+	)
+	cliMu.RUnlock()
+	editor := lineedit.New(st.stdin, st.stdout, path+"> ", s.completeLine)
+	for _, x := range history {
+		editor.AddHistory(x)
+	}
+
+	ctx = NoExit(ctx)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil
+		}
+		line, err := editor.ReadLine()
+		switch {
+		case errors.Is(err, io.EOF):
+			return nil
+		case errors.Is(err, lineedit.ErrInterrupted):
+			continue
+		case err != nil:
+			return fmt.Errorf("%w read line %v", ErrCLIREPLFailure, err)
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		editor.AddHistory(line)
+		if err := appendREPLHistory(historyFile, line); err != nil {
+			return fmt.Errorf("%w write history %v", ErrCLIREPLFailure, err)
+		}
+
+		args, err := shellwords.Split(line)
+		if err != nil {
+			fmt.Fprintf(st.stderr, "Error: %v\n", err)
+			continue
+		}
+		if len(args) == 1 && isREPLExitCommand(args[0]) {
+			return nil
+		}
+		// the error is reported by OnError
+		if err := s.StartWithContext(ctx, args...); err != nil {
+			logger.Debug("REPL %s %v returned error %v", path, args, err)
+		}
+	}
+}
+
+// completeLine returns the candidates for the last word of line.
+func (s *cliMap) completeLine(line string) []string {
+	args, err := shellwords.Split(line)
+	if err != nil {
+		args = strings.Fields(line)
+	}
+	if line == "" || strings.HasSuffix(line, " ") || strings.HasSuffix(line, "\t") {
+		args = append(args, "")
+	}
+	cliMu.RLock()
+	defer cliMu.RUnlock()
+	return s.complete(args)
+}
+
+func readREPLHistory(name string) ([]string, error) {
+	if name == "" {
+		return nil, nil
+	}
+	f, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var (
+		history = []string{}
+		scanner = bufio.NewScanner(f)
+	)
+	for scanner.Scan() {
+		if x := scanner.Text(); x != "" {
+			history = append(history, x)
+		}
+	}
+	return history, scanner.Err()
+}
+
+func appendREPLHistory(name, line string) error {
+	if name == "" {
+		return nil
+	}
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(f, line); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,117 @@
+package fcli_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/berquerant/fcli"
+	"github.com/stretchr/testify/assert"
+)
+
+var replTestRecorder []string
+
+func replTestGreet(name string, loud bool) {
+	if loud {
+		name = strings.ToUpper(name)
+	}
+	replTestRecorder = append(replTestRecorder, "hello "+name)
+}
+
+func withStdin(t *testing.T, input string, f func()) {
+	name := filepath.Join(t.TempDir(), "stdin")
+	if !assert.Nil(t, os.WriteFile(name, []byte(input), 0600)) {
+		return
+	}
+	r, err := os.Open(name)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer r.Close()
+	stdin := os.Stdin
+	os.Stdin = r
+	defer func() {
+		os.Stdin = stdin
+	}()
+	f()
+}
+
+func TestCLIREPL(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		input   string
+		want    []string
+		history []string
+	}{
+		{
+			name:  "empty",
+			input: "",
+			want:  []string{},
+		},
+		{
+			name: "call many times",
+			input: `greet -name alice -loud
+greet
+greet -name bob
+`,
+			want: []string{"hello ALICE", "hello ", "hello bob"},
+		},
+		{
+			name: "quotes",
+			input: `greet -name "alice and bob"
+greet -name 'it'\''s me'`,
+			want: []string{"hello alice and bob", "hello it's me"},
+		},
+		{
+			name: "keep running after errors",
+			input: `unknown
+greet -unknown
+greet -name 'unterminated
+greet -name carol
+`,
+			want: []string{"hello carol"},
+		},
+		{
+			name: "exit",
+			input: `greet -name alice
+exit
+greet -name bob
+`,
+			want: []string{"hello alice"},
+		},
+		{
+			name: "history",
+			input: `greet -name alice
+
+quit
+`,
+			want:    []string{"hello alice"},
+			history: []string{"greet -name old", "greet -name alice", "quit"},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			replTestRecorder = []string{}
+			cli := fcli.NewCLI("tool")
+			cli.OnError(func(error) int { return fcli.Cerror })
+			assert.Nil(t, cli.Add(replTestGreet, fcli.WithCommandName("greet")))
+
+			historyFile := filepath.Join(t.TempDir(), "history")
+			assert.Nil(t, os.WriteFile(historyFile, []byte("greet -name old\n"), 0600))
+			withStdin(t, tc.input, func() {
+				captureStdout(t, func() {
+					assert.Nil(t, cli.REPL(context.Background(), historyFile))
+				})
+			})
+			assert.Equal(t, tc.want, replTestRecorder)
+			if tc.history == nil {
+				return
+			}
+			b, err := os.ReadFile(historyFile)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.history, strings.Split(strings.TrimSuffix(string(b), "\n"), "\n"))
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package fcli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/berquerant/fcli/internal/logger"
+)
+
+var (
+	ErrCLIInterrupted = errors.New("interrupted")
+	// ErrCLIGracePeriodExceeded is the error set to InterruptedError
+	// if the function did not return within the grace period.
+	ErrCLIGracePeriodExceeded = errors.New("grace period exceeded")
+)
+
+// InterruptedError is the error returned if the call is canceled by a signal.
+// errors.Is(err, ErrCLIInterrupted) is true.
+type InterruptedError struct {
+	// Signal is the received signal.
+	Signal os.Signal
+	// Err is the error returned by the function,
+	// or ErrCLIGracePeriodExceeded if the function did not return.
+	Err error
+}
+
+func (e *InterruptedError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("%v by %v", ErrCLIInterrupted, e.Signal)
+	}
+	return fmt.Sprintf("%v by %v %v", ErrCLIInterrupted, e.Signal, e.Err)
+}
+
+func (e *InterruptedError) Is(target error) bool { return target == ErrCLIInterrupted }
+func (e *InterruptedError) Unwrap() error        { return e.Err }
+
+// shutdownSignals cancel the context passed to the function.
+var shutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// signalExitCode returns the exit status by the signal, like a shell.
+func signalExitCode(sig os.Signal) int {
+	if x, ok := sig.(syscall.Signal); ok {
+		return 128 + int(x)
+	}
+	return 1
+}
+
+// signalGracePeriod returns the grace period set by HandleSignals of s or the nearest parent.
+// Returns false if not set.
+// Requires cliMu.
+func (s *cliMap) signalGracePeriod() (time.Duration, bool) {
+	for x := s; x != nil; x = x.parent {
+		if x.handleSignals {
+			return x.gracePeriod, true
+		}
+	}
+	return 0, false
+}
+
+// callWithSignals calls f and cancels the context on the signals if enabled by HandleSignals.
+func (s *cliMap) callWithSignals(ctx context.Context, f func(context.Context) error) error {
+	cliMu.RLock()
+	gracePeriod, ok := s.signalGracePeriod()
+	cliMu.RUnlock()
+	if !ok {
+		return f(ctx)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sigC := make(chan os.Signal, 1)
+	signal.Notify(sigC, shutdownSignals...)
+	defer signal.Stop(sigC)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- f(ctx)
+	}()
+
+	var sig os.Signal
+	select {
+	case err := <-done:
+		return err
+	case sig = <-sigC:
+	}
+
+	logger.Debug("Received %v, wait %v for shutdown", sig, gracePeriod)
+	cancel()
+	timer := time.NewTimer(gracePeriod)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return &InterruptedError{
+			Signal: sig,
+			Err:    err,
+		}
+	case <-timer.C:
+		return &InterruptedError{
+			Signal: sig,
+			Err:    ErrCLIGracePeriodExceeded,
+		}
+	case sig = <-sigC:
+		logger.Debug("Received %v again, exit", sig)
+		os.Exit(signalExitCode(sig))
+		return nil
+	}
+}
+
+func (s *cliMap) HandleSignals(gracePeriod time.Duration) {
+	cliMu.Lock()
+	defer cliMu.Unlock()
+	s.handleSignals = true
+	s.gracePeriod = gracePeriod
+}
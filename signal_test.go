@@ -0,0 +1,71 @@
+//go:build linux || darwin
+
+package fcli_test
+
+import (
+	"context"
+	"flag"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/berquerant/fcli"
+	"github.com/stretchr/testify/assert"
+)
+
+func signalTestWait(ctx context.Context, ignoreCancel bool) error {
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGINT); err != nil {
+		return err
+	}
+	if ignoreCancel {
+		time.Sleep(time.Second)
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Second):
+		return nil
+	}
+}
+
+func TestCLIHandleSignals(t *testing.T) {
+	cli := fcli.NewCLI("tool")
+	cli.OnError(func(error) int { return fcli.Cerror })
+	cli.HandleSignals(100 * time.Millisecond)
+	assert.Nil(t, cli.Add(signalTestWait, fcli.WithCommandName("wait"), fcli.WithErrorHandling(flag.ContinueOnError)))
+
+	t.Run("canceled", func(t *testing.T) {
+		err := cli.Start("wait")
+		assert.ErrorIs(t, err, fcli.ErrCLIInterrupted)
+		assert.ErrorIs(t, err, context.Canceled)
+		var e *fcli.InterruptedError
+		if assert.ErrorAs(t, err, &e) {
+			assert.Equal(t, syscall.SIGINT, e.Signal)
+		}
+	})
+	t.Run("grace period exceeded", func(t *testing.T) {
+		err := cli.Start("wait", "-ignoreCancel")
+		assert.ErrorIs(t, err, fcli.ErrCLIInterrupted)
+		assert.ErrorIs(t, err, fcli.ErrCLIGracePeriodExceeded)
+	})
+}
+
+func TestCLIHandleSignalsInGroup(t *testing.T) {
+	opt := fcli.WithErrorHandling(flag.ContinueOnError)
+	t.Run("set to group", func(t *testing.T) {
+		cli := fcli.NewCLI("tool")
+		cli.OnError(func(error) int { return fcli.Cerror })
+		db := cli.Group("db")
+		db.HandleSignals(100 * time.Millisecond)
+		assert.Nil(t, db.Add(signalTestWait, fcli.WithCommandName("wait"), opt))
+		assert.ErrorIs(t, cli.Start("db", "wait"), context.Canceled)
+	})
+	t.Run("inherited from parent", func(t *testing.T) {
+		cli := fcli.NewCLI("tool")
+		cli.OnError(func(error) int { return fcli.Cerror })
+		cli.HandleSignals(100 * time.Millisecond)
+		assert.Nil(t, cli.Group("db").Add(signalTestWait, fcli.WithCommandName("wait"), opt))
+		assert.ErrorIs(t, cli.Start("db", "wait", "-ignoreCancel"), fcli.ErrCLIGracePeriodExceeded)
+	})
+}
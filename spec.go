@@ -0,0 +1,184 @@
+package fcli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+)
+
+// specCommandName is the hidden command to write the spec.
+// `tool __spec` is the same as WriteSpec(os.Stdout).
+const specCommandName = "__spec"
+
+// SpecVersion is the version of the spec format.
+// Incremented when the format is changed incompatibly.
+const SpecVersion = 1
+
+// Spec is the machine-readable description of the CLI.
+type Spec struct {
+	// Version is SpecVersion.
+	Version int `json:"version"`
+	GroupSpec
+}
+
+// GroupSpec is the description of the CLI or the group.
+type GroupSpec struct {
+	// Name is the name of the CLI or the group.
+	Name string `json:"name"`
+	// Path is the names from the CLI separated by space.
+	Path string `json:"path"`
+	// Description is the text set by WithDescription.
+	Description string `json:"description"`
+	// GlobalFlags is the flags before the command name.
+	GlobalFlags []FlagSpec `json:"globalFlags"`
+	// Commands is the commands in usage order, including the hidden commands.
+	Commands []CommandSpec `json:"commands"`
+	// Groups is the groups in usage order.
+	Groups []GroupSpec `json:"groups"`
+}
+
+// CommandSpec is the description of the command.
+type CommandSpec struct {
+	Name       string     `json:"name"`
+	Path       string     `json:"path"`
+	Aliases    []string   `json:"aliases"`
+	Category   string     `json:"category"`
+	Hidden     bool       `json:"hidden"`
+	Deprecated string     `json:"deprecated"`
+	Summary    string     `json:"summary"`
+	Doc        string     `json:"doc"`
+	Flags      []FlagSpec `json:"flags"`
+}
+
+// FlagSpec is the description of the flag.
+type FlagSpec struct {
+	Name string `json:"name"`
+	// Type is the Go type.
+	Type string `json:"type"`
+	// Kind is the kind of the flag, see ParamInfo.
+	Kind    string `json:"kind"`
+	Default string `json:"default"`
+	Usage   string `json:"usage"`
+	// Schema is the JSON Schema of the value.
+	Schema map[string]any `json:"schema"`
+}
+
+// jsonSchema returns the JSON Schema of the value of the flag of the type.
+func jsonSchema(t reflect.Type) map[string]any {
+	v := reflect.Zero(t).Interface()
+	if x, ok := v.(CustomFlagJSONSchemaer); ok {
+		return x.FlagJSONSchema()
+	}
+	if _, ok := v.(CustomFlagUnmarshaller); ok {
+		return map[string]any{"type": "string"}
+	}
+
+	integer := func(min, max any) map[string]any {
+		r := map[string]any{"type": "integer"}
+		if min != nil {
+			r["minimum"] = min
+		}
+		if max != nil {
+			r["maximum"] = max
+		}
+		return r
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int64:
+		return integer(nil, nil)
+	case reflect.Int8:
+		return integer(math.MinInt8, math.MaxInt8)
+	case reflect.Int16:
+		return integer(math.MinInt16, math.MaxInt16)
+	case reflect.Int32:
+		return integer(math.MinInt32, math.MaxInt32)
+	case reflect.Uint, reflect.Uint64:
+		return integer(0, nil)
+	case reflect.Uint8:
+		return integer(0, math.MaxUint8)
+	case reflect.Uint16:
+		return integer(0, math.MaxUint16)
+	case reflect.Uint32:
+		return integer(0, int64(math.MaxUint32))
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	}
+	return map[string]any{}
+}
+
+func newFlagSpec(f Flag, t reflect.Type, x *flag.Flag) FlagSpec {
+	r := FlagSpec{
+		Name:   f.Name(),
+		Type:   t.String(),
+		Kind:   flagKind(f),
+		Schema: jsonSchema(t),
+	}
+	if x != nil {
+		r.Default = x.DefValue
+		r.Usage = x.Usage
+	}
+	return r
+}
+
+func (s *targetFunction) spec(path string) CommandSpec {
+	r := CommandSpec{
+		Name:       s.Name(),
+		Path:       path,
+		Aliases:    append([]string{}, s.Aliases()...),
+		Category:   s.Category(),
+		Hidden:     s.Hidden(),
+		Deprecated: s.Deprecated(),
+		Summary:    summary(s.Doc()),
+		Doc:        s.Doc(),
+		Flags:      []FlagSpec{},
+	}
+	for i, f := range s.flags {
+		r.Flags = append(r.Flags, newFlagSpec(f, s.flagTypes[i], s.flagSet.Lookup(f.Name())))
+	}
+	return r
+}
+
+// groupSpec returns the spec of s and the groups.
+// Requires cliMu.
+func (s *cliMap) groupSpec() GroupSpec {
+	r := GroupSpec{
+		Name:        s.name,
+		Path:        s.path(),
+		Description: s.description(),
+		GlobalFlags: []FlagSpec{},
+		Commands:    []CommandSpec{},
+		Groups:      []GroupSpec{},
+	}
+	flagSet, flags := s.newGlobalFlagSet()
+	for i, f := range flags {
+		r.GlobalFlags = append(r.GlobalFlags, newFlagSpec(f, s.globals[i].typ, flagSet.Lookup(f.Name())))
+	}
+	for _, name := range s.sortedNames() {
+		if g, ok := s.groups[name]; ok {
+			r.Groups = append(r.Groups, g.groupSpec())
+			continue
+		}
+		r.Commands = append(r.Commands, s.commands[name].spec(fmt.Sprintf("%s %s", s.path(), name)))
+	}
+	return r
+}
+
+func (s *cliMap) WriteSpec(w io.Writer) error {
+	cliMu.RLock()
+	spec := &Spec{
+		Version:   SpecVersion,
+		GroupSpec: s.groupSpec(),
+	}
+	cliMu.RUnlock()
+
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(spec)
+}
@@ -0,0 +1,129 @@
+package fcli_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/berquerant/fcli"
+	"github.com/stretchr/testify/assert"
+)
+
+type specTestLevel string
+
+func (specTestLevel) UnmarshalFlag(v string) (fcli.CustomFlagUnmarshaller, error) {
+	return specTestLevel(v), nil
+}
+
+func (specTestLevel) FlagJSONSchema() map[string]any {
+	return map[string]any{
+		"type": "string",
+		"enum": []string{"debug", "info"},
+	}
+}
+
+// specTestDeploy deploys the app.
+// Second line.
+func specTestDeploy(replicas uint8, level specTestLevel, tags *stringList) {}
+
+func TestCLIWriteSpec(t *testing.T) {
+	cli := fcli.NewCLI("tool")
+	assert.Nil(t, cli.AddGlobalFlag("v", false, ""))
+	app := cli.Group("app")
+	app.Configure(fcli.WithDescription("Manage the apps."))
+	assert.Nil(t, app.Add(specTestDeploy,
+		fcli.WithCommandName("deploy"),
+		fcli.WithAliases([]string{"d"}),
+		fcli.WithCategory("Release"),
+	))
+
+	var buf bytes.Buffer
+	assert.Nil(t, cli.WriteSpec(&buf))
+	assert.Equal(t, `{
+  "version": 1,
+  "name": "tool",
+  "path": "tool",
+  "description": "",
+  "globalFlags": [
+    {
+      "name": "v",
+      "type": "bool",
+      "kind": "bool",
+      "default": "false",
+      "usage": "",
+      "schema": {
+        "type": "boolean"
+      }
+    }
+  ],
+  "commands": [],
+  "groups": [
+    {
+      "name": "app",
+      "path": "tool app",
+      "description": "Manage the apps.",
+      "globalFlags": [],
+      "commands": [
+        {
+          "name": "deploy",
+          "path": "tool app deploy",
+          "aliases": [
+            "d"
+          ],
+          "category": "Release",
+          "hidden": false,
+          "deprecated": "",
+          "summary": "specTestDeploy deploys the app.",
+          "doc": "specTestDeploy deploys the app.\nSecond line.\n",
+          "flags": [
+            {
+              "name": "replicas",
+              "type": "uint8",
+              "kind": "uint8",
+              "default": "0",
+              "usage": "",
+              "schema": {
+                "maximum": 255,
+                "minimum": 0,
+                "type": "integer"
+              }
+            },
+            {
+              "name": "level",
+              "type": "fcli_test.specTestLevel",
+              "kind": "custom",
+              "default": "",
+              "usage": "",
+              "schema": {
+                "enum": [
+                  "debug",
+                  "info"
+                ],
+                "type": "string"
+              }
+            },
+            {
+              "name": "tags",
+              "type": "*fcli_test.stringList",
+              "kind": "custom",
+              "default": "",
+              "usage": "",
+              "schema": {
+                "type": "string"
+              }
+            }
+          ]
+        }
+      ],
+      "groups": []
+    }
+  ]
+}
+`, buf.String())
+
+	// the hidden command
+	got := captureStdout(t, func() {
+		assert.Nil(t, cli.Start("__spec"))
+	})
+	assert.True(t, strings.HasPrefix(got, "{\n  \"version\": 1,"))
+}
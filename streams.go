@@ -0,0 +1,93 @@
+package fcli
+
+import (
+	"context"
+	"io"
+	"os"
+)
+
+// streams is the input and the outputs of the CLI.
+type streams struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// stdStreams returns the current os.Stdin, os.Stdout and os.Stderr.
+func stdStreams() *streams {
+	return &streams{
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	}
+}
+
+type streamsKey struct{}
+
+func withStreams(ctx context.Context, st *streams) context.Context {
+	return context.WithValue(ctx, streamsKey{}, st)
+}
+
+// streamsFrom returns the streams in ctx, the standard streams if not exist.
+func streamsFrom(ctx context.Context) *streams {
+	if st, ok := ctx.Value(streamsKey{}).(*streams); ok {
+		return st
+	}
+	return stdStreams()
+}
+
+type callStreamsKey struct{}
+
+// WithStreams returns the context with which the CLI reads and writes the streams
+// instead of the streams set by WithStdin, WithStdout and WithStderr and the standard streams.
+// nil keeps the stream.
+// Intended for the tests, see fclitest.
+func WithStreams(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) context.Context {
+	return context.WithValue(ctx, callStreamsKey{}, &streams{
+		stdin:  stdin,
+		stdout: stdout,
+		stderr: stderr,
+	})
+}
+
+// Stdin returns the stdin of the CLI that calls the function, os.Stdin if not called by the CLI.
+func Stdin(ctx context.Context) io.Reader { return streamsFrom(ctx).stdin }
+
+// Stdout returns the stdout of the CLI that calls the function, os.Stdout if not called by the CLI.
+func Stdout(ctx context.Context) io.Writer { return streamsFrom(ctx).stdout }
+
+// Stderr returns the stderr of the CLI that calls the function, os.Stderr if not called by the CLI.
+func Stderr(ctx context.Context) io.Writer { return streamsFrom(ctx).stderr }
+
+// streams returns the streams set by WithStreams to ctx,
+// and WithStdin, WithStdout and WithStderr inherited from the parent.
+// The standard streams are used if not set.
+// Requires cliMu.
+func (s *cliMap) streams(ctx context.Context) *streams {
+	st := &streams{}
+	if x, ok := ctx.Value(callStreamsKey{}).(*streams); ok {
+		*st = *x
+	}
+	for x := s; x != nil; x = x.parent {
+		if st.stdin == nil {
+			st.stdin = x.config.Stdin.Get()
+		}
+		if st.stdout == nil {
+			st.stdout = x.config.Stdout.Get()
+		}
+		if st.stderr == nil {
+			st.stderr = x.config.Stderr.Get()
+		}
+	}
+	std := stdStreams()
+	if st.stdin == nil {
+		st.stdin = std.stdin
+	}
+	if st.stdout == nil {
+		st.stdout = std.stdout
+	}
+	if st.stderr == nil {
+		st.stderr = std.stderr
+	}
+	return st
+}
@@ -0,0 +1,192 @@
+package fcli_test
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/berquerant/fcli"
+	"github.com/stretchr/testify/assert"
+)
+
+// streamsTestEcho prints the lines of stdin.
+func streamsTestEcho(ctx context.Context, prefix string) error {
+	b, err := io.ReadAll(fcli.Stdin(ctx))
+	if err != nil {
+		return err
+	}
+	for _, x := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		fmt.Fprintf(fcli.Stdout(ctx), "%s%s\n", prefix, x)
+	}
+	fmt.Fprintln(fcli.Stderr(ctx), "done")
+	return nil
+}
+
+func TestCLIStreams(t *testing.T) {
+	var (
+		stdout bytes.Buffer
+		stderr bytes.Buffer
+		cli    = fcli.NewCLI("tool",
+			fcli.WithStdin(strings.NewReader("a\nb\n")),
+			fcli.WithStdout(&stdout),
+			fcli.WithStderr(&stderr),
+			fcli.WithErrorHandling(flag.ContinueOnError),
+			fcli.WithDescription("Tool does things."),
+		)
+		db = cli.Group("db")
+	)
+	db.Configure(fcli.WithDescription("Manage the database.\nSecond line."))
+	assert.Nil(t, cli.Add(streamsTestEcho, fcli.WithCommandName("echo")))
+	assert.Nil(t, db.Add(streamsTestEcho, fcli.WithCommandName("echo")))
+
+	reset := func() {
+		stdout.Reset()
+		stderr.Reset()
+	}
+
+	t.Run("function", func(t *testing.T) {
+		defer reset()
+		assert.Nil(t, cli.Start("echo", "-prefix", "> "))
+		assert.Equal(t, "> a\n> b\n", stdout.String())
+		assert.Equal(t, "done\n", stderr.String())
+	})
+
+	t.Run("help", func(t *testing.T) {
+		defer reset()
+		assert.Nil(t, cli.Start("help"))
+		assert.Equal(t, `Usage: tool <command> [arguments]
+
+Tool does things.
+
+Commands:
+  db    Manage the database.
+  echo  streamsTestEcho prints the lines of stdin.
+
+Run 'tool help <command>' for more information.
+`, stdout.String())
+		assert.Equal(t, "", stderr.String())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		defer reset()
+		assert.ErrorIs(t, cli.Start("ech"), fcli.ErrCLICommandNotFound)
+		assert.Equal(t, "", stdout.String())
+		got := stderr.String()
+		assert.True(t, strings.HasPrefix(got, "Error: command not found tool ech\nDid you mean this?\n\techo\nUsage: tool"), got)
+	})
+
+	t.Run("flag error in group", func(t *testing.T) {
+		defer reset()
+		assert.ErrorIs(t, cli.Start("db", "echo", "-prefx", "x"), fcli.ErrParseFailure)
+		assert.Equal(t, "", stdout.String())
+		got := stderr.String()
+		assert.True(t, strings.HasPrefix(got, "flag provided but not defined: -prefx\nstreamsTestEcho prints the lines of stdin.\nUsage of echo:\n"), got)
+		assert.Contains(t, got, "Usage: tool db <command> [arguments]\n\nManage the database.\nSecond line.\n")
+	})
+}
+
+func TestCLIWithStreams(t *testing.T) {
+	var (
+		configured bytes.Buffer
+		stdout     bytes.Buffer
+		stderr     bytes.Buffer
+		cli        = fcli.NewCLI("tool",
+			fcli.WithStdout(&configured),
+			fcli.WithStderr(&configured),
+			fcli.WithErrorHandling(flag.ContinueOnError),
+		)
+		ctx = fcli.WithStreams(context.Background(), strings.NewReader("a\n"), &stdout, &stderr)
+	)
+	assert.Nil(t, cli.Group("db").Add(streamsTestEcho, fcli.WithCommandName("echo")))
+
+	assert.Nil(t, cli.StartWithContext(ctx, "db", "echo", "-prefix", "> "))
+	assert.Equal(t, "> a\n", stdout.String())
+	assert.Equal(t, "done\n", stderr.String())
+
+	stdout.Reset()
+	stderr.Reset()
+	assert.ErrorIs(t, cli.StartWithContext(ctx, "db", "ech"), fcli.ErrCLICommandNotFound)
+	assert.Equal(t, "", stdout.String())
+	assert.Contains(t, stderr.String(), "Error: command not found tool db ech\n")
+	assert.Contains(t, stderr.String(), "Usage: tool db <command> [arguments]\n")
+
+	assert.Equal(t, "", configured.String())
+}
+
+func TestGetenv(t *testing.T) {
+	t.Setenv("FCLI_TEST_GETENV_PROCESS", "process")
+	ctx := fcli.WithEnv(context.Background(), map[string]string{"FCLI_TEST_GETENV": "ctx"})
+	ctx = fcli.WithEnv(ctx, map[string]string{"FCLI_TEST_GETENV_CHILD": "child"})
+
+	assert.Equal(t, "ctx", fcli.Getenv(ctx, "FCLI_TEST_GETENV"))
+	assert.Equal(t, "child", fcli.Getenv(ctx, "FCLI_TEST_GETENV_CHILD"))
+	assert.Equal(t, "process", fcli.Getenv(ctx, "FCLI_TEST_GETENV_PROCESS"))
+	assert.Equal(t, "", fcli.Getenv(context.Background(), "FCLI_TEST_GETENV"))
+}
+
+func TestCLICommandDefaults(t *testing.T) {
+	cli := fcli.NewCLI("tool", fcli.WithCategory("Main"))
+	g := cli.Group("db")
+	g.Configure(fcli.WithHidden(true))
+	assert.Nil(t, cli.Add(streamsTestEcho, fcli.WithCommandName("echo")))
+	assert.Nil(t, cli.Add(streamsTestEcho, fcli.WithCommandName("echo2"), fcli.WithCategory("Sub")))
+	assert.Nil(t, g.Add(streamsTestEcho, fcli.WithCommandName("echo")))
+	cli.Configure(fcli.WithCategory("Late"))
+	assert.Nil(t, cli.Add(streamsTestEcho, fcli.WithCommandName("echo3")))
+
+	got := map[string][2]any{}
+	for _, x := range cli.Commands() {
+		got[x.Path] = [2]any{x.Category, x.Hidden}
+	}
+	assert.Equal(t, map[string][2]any{
+		"tool echo":    {"Main", false},
+		"tool echo2":   {"Sub", false},
+		"tool echo3":   {"Late", false},
+		"tool db echo": {"Main", true},
+	}, got)
+}
+
+func TestCLICommandOptionsIgnored(t *testing.T) {
+	var calls []string
+	cli := fcli.NewCLI("tool",
+		fcli.WithCommandName("x"),
+		fcli.WithAliases([]string{"a"}),
+		fcli.WithParamNames([]string{"p"}),
+		fcli.WithInterceptors([]fcli.Interceptor{
+			func(ctx context.Context, inv *fcli.Invocation, next func(context.Context) error) error {
+				calls = append(calls, inv.Name)
+				return next(ctx)
+			},
+		}),
+	)
+	cli.OnError(func(error) int { return fcli.Cerror })
+	assert.Nil(t, cli.Add(streamsTestEcho, fcli.WithCommandName("echo")))
+	assert.Nil(t, cli.Add(streamsTestStatus))
+
+	got := cli.Commands()
+	if assert.Equal(t, 2, len(got)) {
+		assert.Equal(t, "echo", got[0].Name)
+		assert.Equal(t, 0, len(got[0].Aliases))
+		assert.Equal(t, "prefix", got[0].Params[0].Name)
+		assert.Equal(t, "streamsTestStatus", got[1].Name)
+	}
+
+	// WithInterceptors is the same as Use
+	assert.Nil(t, cli.Start("streamsTestStatus"))
+	assert.Equal(t, []string{"streamsTestStatus"}, calls)
+
+	var e *fcli.ValidationError
+	if assert.ErrorAs(t, cli.Validate(), &e) {
+		assert.Equal(t, []string{
+			"tool: option WithCommandName is for the commands, ignored",
+			"tool: option WithAliases is for the commands, ignored",
+			"tool: option WithParamNames is for the commands, ignored",
+		}, e.Problems)
+	}
+}
+
+func streamsTestStatus() {}
@@ -5,24 +5,53 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"reflect"
+	"strings"
 
 	"github.com/berquerant/fcli/internal/ierrors"
 	"github.com/berquerant/fcli/internal/logger"
+	"github.com/berquerant/fcli/internal/suggest"
 )
 
 var (
 	ErrBadTargetFunction = errors.New("bad target function")
 	ErrCallFailure       = errors.New("call failure")
+	// ErrParseFailure is the ErrCallFailure returned if failed to parse the flags.
+	ErrParseFailure = fmt.Errorf("%w parse", ErrCallFailure)
 )
 
+// UnknownFlagError is the error returned if the flag is not defined.
+type UnknownFlagError struct {
+	// Command is the name of the function.
+	Command string
+	// Name is the given flag name without dashes.
+	Name string
+	// Suggestions is the flag names close to Name, the closest first.
+	Suggestions []string
+}
+
+func (e *UnknownFlagError) Error() string {
+	return fmt.Sprintf("%v err flag provided but not defined: -%s", ErrParseFailure, e.Name)
+}
+
+func (e *UnknownFlagError) Unwrap() error { return ErrParseFailure }
+
+const undefinedFlagErrorPrefix = "flag provided but not defined: -"
+
 // TargetFunction specifies a function for CLI subcommand.
 type TargetFunction interface {
 	// Name returns the name of the function.
 	Name() string
 	// Unwrap returns raw function.
 	Unwrap() any
+	// Doc returns the doc comment of the function.
+	Doc() string
+	// FuncName returns the name and the location of the function.
+	FuncName() *FuncName
+	// Params returns the metadata of the parameters except context.Context.
+	Params() []ParamInfo
 	// Call calls the function by flag arguments.
 	// Returns ErrCallFailure if failed to call the function.
 	Call(arguments []string) error
@@ -30,14 +59,21 @@ type TargetFunction interface {
 }
 
 type targetFunction struct {
-	f       any
-	flags   []Flag
-	flagSet *flag.FlagSet
-	config  *Config
+	f     any
+	fname *FuncName
+	// flags and flagSet describe the flags, the values are not used.
+	// Each call parses the arguments by the new flags, see newFlags.
+	flags         []Flag
+	flagFactories []FlagFactory
+	flagTypes     []reflect.Type
+	flagSet       *flag.FlagSet
+	config        *Config
+	doc           string
 }
 
 // NewTargetFunction makes a function able to be invoked by string slice arguments.
-// F can be the function which is not variadic, no output parameters or an error, not literal, not method
+// F can be the function which is not variadic, not method value,
+// has no output parameters, an error or (int, error)
 // and can have input parameters below:
 //
 //   int, int8, int16, int32, int64
@@ -48,7 +84,15 @@ type targetFunction struct {
 // First input argument can be context.Context.
 // Default value is available if the type implements CustomFlagZeroer.
 // Note: if pass the struct, pass as a pointer.
+// The int of (int, error) is the exit status, see ExitError.
+// The flag names and the doc are read from the func decl in the source.
+// WithParamNames and WithDoc give them explicitly, e.g. for the function literal.
+// If the func decl is not found, the flag names are arg0, arg1, ...
 func NewTargetFunction(f any, opt ...Option) (TargetFunction, error) {
+	return newTargetFunction(f, opt...)
+}
+
+func newTargetFunction(f any, opt ...Option) (*targetFunction, error) {
 	t := reflect.TypeOf(f)
 	if t.Kind() != reflect.Func {
 		return nil, fmt.Errorf("%w not a function %v", ErrBadTargetFunction, f)
@@ -58,7 +102,13 @@ func NewTargetFunction(f any, opt ...Option) (TargetFunction, error) {
 	if err != nil {
 		return nil, fmt.Errorf("%w cannot get function name from %v", ErrBadTargetFunction, f)
 	}
+	return newTargetFunctionWithName(f, fname, opt...)
+}
 
+// newTargetFunctionWithName builds the target function of f by the name and the location fname.
+// fname may be different from the name of f, e.g. the method expression for the method value f.
+func newTargetFunctionWithName(f any, fname *FuncName, opt ...Option) (*targetFunction, error) {
+	t := reflect.TypeOf(f)
 	wrapErr := ierrors.NewWrapperBuilder().
 		Err(ErrBadTargetFunction).
 		Msg("%s", fname.FullName()).
@@ -66,16 +116,30 @@ func NewTargetFunction(f any, opt ...Option) (TargetFunction, error) {
 	if t.IsVariadic() {
 		return nil, wrapErr("variadic")
 	}
-	if !(t.NumOut() == 0 || t.NumOut() == 1 && t.Out(0).String() == "error") {
-		return nil, wrapErr("has output parameters except error")
-	}
-	// read function AST
-	funcInfo, err := BuildFuncInfo(fname.File(), fname.Line())
-	if err != nil {
-		return nil, wrapErr("build func info %w", err)
+	switch {
+	case t.NumOut() == 0:
+	case t.NumOut() == 1 && t.Out(0).String() == "error":
+	case t.NumOut() == 2 && t.Out(0).String() == "int" && t.Out(1).String() == "error":
+	default:
+		return nil, wrapErr("has output parameters except error, (int, error)")
 	}
+	// apply options
+	config := NewConfigBuilder().
+		ErrorHandling(flag.ExitOnError).
+		CommandName(fname.String()).
+		Aliases([]string{}).
+		Interceptors([]Interceptor{}).
+		DeprecatedFlags(map[string]string{}).
+		RenamedFlags(map[string]string{}).
+		ParamNames([]string{}).
+		Build()
+	config.Apply(opt...)
 	// generate flags from function
-	flags := []Flag{}
+	var (
+		flagFactories = []FlagFactory{}
+		flagTypes     = []reflect.Type{}
+		flagIndexes   = []int{}
+	)
 	for i := 0; i < t.NumIn(); i++ {
 		p := t.In(i)
 		if p.String() == "context.Context" {
@@ -85,62 +149,212 @@ func NewTargetFunction(f any, opt ...Option) (TargetFunction, error) {
 		if !found {
 			return nil, wrapErr("unsupported parameter type %v", p)
 		}
-		flags = append(flags, ff(funcInfo.In(i).Name()))
+		flagFactories = append(flagFactories, ff)
+		flagTypes = append(flagTypes, p)
+		flagIndexes = append(flagIndexes, i)
 	}
-	// apply options
-	config := NewConfigBuilder().
-		ErrorHandling(flag.ExitOnError).
-		CommandName(fname.String()).
-		Build()
-	config.Apply(opt...)
-	// init flags
-	flagSet := flag.NewFlagSet(
-		config.CommandName.Get(),
-		config.ErrorHandling.Get(),
+	flagNames, doc, err := newParamNames(t, fname, config, flagIndexes)
+	if err != nil {
+		return nil, wrapErr("%w", err)
+	}
+
+	tf := &targetFunction{
+		f:             f,
+		fname:         fname,
+		flagFactories: flagFactories,
+		flagTypes:     flagTypes,
+		config:        config,
+		doc:           doc,
+	}
+	tf.flags, tf.flagSet = tf.newFlags(flagNames)
+	return tf, nil
+}
+
+// newParamNames returns the flag names and the doc of the function of type t.
+// flagIndexes is the indexes of the input parameters that are flags.
+// The names are from ParamNames if set, otherwise from the func decl.
+// The positional names like arg0, arg1 are used if the func decl is not found, e.g. the function literal.
+// The doc is from Doc if set, otherwise from the func decl, and ends with a newline if not empty.
+func newParamNames(t reflect.Type, fname *FuncName, config *Config, flagIndexes []int) ([]string, string, error) {
+	var (
+		names  = make([]string, len(flagIndexes))
+		doc    = config.Doc.Get()
+		custom = config.ParamNames.Get()
 	)
-	if doc := funcInfo.Doc(); doc != "" {
-		flagSet.Usage = func() {
-			fmt.Fprintf(os.Stderr, doc)
+	if len(custom) > 0 && len(custom) != len(flagIndexes) {
+		return nil, "", fmt.Errorf("%d param names for %d params", len(custom), len(flagIndexes))
+	}
+	funcInfo, err := BuildFuncInfo(fname.File(), fname.Line())
+	// trim the type arguments like F[...]
+	name, _, _ := strings.Cut(fname.String(), "[")
+	if err == nil && (funcInfo.Name() != name || funcInfo.NumIn() != t.NumIn()) {
+		// e.g. the func decl that contains the function literal
+		err = fmt.Errorf("func decl %s does not match", funcInfo.Name())
+	}
+	switch {
+	case len(custom) > 0:
+		copy(names, custom)
+	case err != nil:
+		logger.Debug("Use positional param names for %s: %v", fname.FullName(), err)
+	default:
+		for i, x := range flagIndexes {
+			names[i] = funcInfo.In(x).Name()
 		}
 	}
-	for _, f := range flags {
-		f.AddFlag(flagSet)
+	if err == nil && !config.Doc.IsModified() {
+		doc = funcInfo.Doc()
 	}
+	if doc != "" && !strings.HasSuffix(doc, "\n") {
+		doc += "\n"
+	}
+	seen := map[string]bool{}
+	for i, name := range names {
+		if name == "" || name == "_" {
+			names[i] = fmt.Sprintf("arg%d", i)
+		}
+		if seen[names[i]] {
+			return nil, "", fmt.Errorf("duplicate param name %s", names[i])
+		}
+		seen[names[i]] = true
+	}
+	return names, doc, nil
+}
 
-	return &targetFunction{
-		f:       f,
-		flags:   flags,
-		config:  config,
-		flagSet: flagSet,
-	}, nil
+// newFlags returns the new flags and the flag set that defines them.
+// If names is nil, uses the names of the flags.
+func (s *targetFunction) newFlags(names []string) ([]Flag, *flag.FlagSet) {
+	if names == nil {
+		names = make([]string, len(s.flags))
+		for i, f := range s.flags {
+			names[i] = f.Name()
+		}
+	}
+	// handle errors after parse to add suggestions, see parseFlags
+	flagSet := flag.NewFlagSet(
+		s.config.CommandName.Get(),
+		flag.ContinueOnError,
+	)
+	flags := make([]Flag, len(names))
+	for i, name := range names {
+		flags[i] = s.flagFactories[i](name)
+		flags[i].AddFlag(flagSet)
+		if usage, ok := s.deprecatedFlagUsage(name); ok {
+			flagSet.Lookup(name).Usage = usage
+		}
+	}
+	flagSet.Usage = func() {
+		s.writeUsage(flagSet.Output())
+	}
+	return flags, flagSet
 }
 
-func (s *targetFunction) Name() string { return s.flagSet.Name() }
-func (s *targetFunction) Unwrap() any  { return s.f }
+func (s *targetFunction) Name() string       { return s.flagSet.Name() }
+func (s *targetFunction) Aliases() []string  { return s.config.Aliases.Get() }
+func (s *targetFunction) Category() string   { return s.config.Category.Get() }
+func (s *targetFunction) Hidden() bool       { return s.config.Hidden.Get() }
+func (s *targetFunction) Deprecated() string { return s.config.Deprecated.Get() }
+func (s *targetFunction) Unwrap() any        { return s.f }
+func (s *targetFunction) Doc() string        { return s.doc }
+
+// writeUsage prints the doc and the flags.
+func (s *targetFunction) writeUsage(w io.Writer) {
+	if msg := s.Deprecated(); msg != "" {
+		fmt.Fprintf(w, "Deprecated: %s\n", msg)
+	}
+	fmt.Fprint(w, s.doc)
+	if s.doc != "" && len(s.flags) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Usage of %s:\n", s.Name())
+	_, flagSet := s.newFlags(nil)
+	flagSet.SetOutput(w)
+	flagSet.PrintDefaults()
+}
 
 func (s *targetFunction) Call(arguments []string) (rerr error) {
 	return s.CallWithContext(context.Background(), arguments)
 }
 
-func (s *targetFunction) CallWithContext(ctx context.Context, arguments []string) (rerr error) {
+func (s *targetFunction) CallWithContext(ctx context.Context, arguments []string) error {
+	return s.call(ctx, arguments, nil)
+}
+
+// call calls the function through the interceptors and the interceptors of the function.
+func (s *targetFunction) call(ctx context.Context, arguments []string, interceptors []Interceptor) (rerr error) {
 	defer func() {
 		if err := recover(); err != nil {
 			rerr = fmt.Errorf("%w recover %s %v", ErrCallFailure, s.flagSet.Name(), err)
 		}
 	}()
 
-	if err := s.flagSet.Parse(arguments); err != nil {
-		return fmt.Errorf("%w err %v", ErrCallFailure, err)
+	st := streamsFrom(ctx)
+	flags, flagSet := s.newFlags(nil)
+	flagSet.SetOutput(st.stderr)
+	s.addRenamedFlags(flagSet)
+	if err := s.parseFlags(ctx, flagSet, arguments); err != nil {
+		return err
 	}
 
-	inputValues := make([]reflect.Value, len(s.flags))
-	for i, f := range s.flags {
-		v, err := f.ReflectValue()
+	var (
+		inputValues = make([]reflect.Value, len(flags))
+		values      = make(map[string]any, len(flags))
+		visited     = map[string]bool{}
+	)
+	flagSet.Visit(func(f *flag.Flag) {
+		visited[f.Name] = true
+	})
+	s.warnDeprecated(st.stderr, visited)
+	for i, f := range flags {
+		v, err := func() (reflect.Value, error) {
+			// inject the global flag that has the same name and type if the flag is not set
+			if g, ok := GlobalFlag(ctx, f.Name()); ok && !visited[f.Name()] {
+				if v := reflect.ValueOf(g); v.IsValid() && v.Type() == s.flagTypes[i] {
+					logger.Debug("%s inject global flag %s %#v", s.flagSet.Name(), f.Name(), g)
+					return v, nil
+				}
+			}
+			return f.ReflectValue()
+		}()
 		if err != nil {
 			return fmt.Errorf("%w unwrap error %d th arg %s %v", ErrCallFailure, i+1, f.Name(), err)
 		}
 		inputValues[i] = v
+		if v.IsValid() {
+			values[f.Name()] = v.Interface()
+		} else {
+			values[f.Name()] = nil
+		}
+	}
+
+	var (
+		invocation = &Invocation{
+			Name:      s.Name(),
+			Arguments: arguments,
+			Values:    values,
+		}
+		handler = func(ctx context.Context) error {
+			return s.invoke(ctx, inputValues)
+		}
+	)
+	interceptors = append(append([]Interceptor{}, interceptors...), s.config.Interceptors.Get()...)
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		var (
+			interceptor = interceptors[i]
+			next        = handler
+		)
+		handler = func(ctx context.Context) error {
+			return interceptor(ctx, invocation, next)
+		}
 	}
+	if err := handler(ctx); err != nil {
+		logger.Debug("%s(%v) returned error %v", s.flagSet.Name(), arguments, err)
+		return err
+	}
+	return nil
+}
+
+// invoke calls the function by the values of the flags.
+func (s *targetFunction) invoke(ctx context.Context, inputValues []reflect.Value) error {
 	t := reflect.TypeOf(s.f)
 	if t.NumIn() > 0 && t.In(0).String() == "context.Context" {
 		inputValues = append([]reflect.Value{reflect.ValueOf(ctx)}, inputValues...)
@@ -159,10 +373,67 @@ func (s *targetFunction) CallWithContext(ctx context.Context, arguments []string
 			return nil
 		}
 		if err, ok := r0.(error); ok {
-			logger.Debug("%s(%v) returned error %v", s.flagSet.Name(), arguments, err)
 			return err
 		}
+	case 2:
+		code, ok := resultValues[0].(int)
+		if !ok {
+			break
+		}
+		err, _ := resultValues[1].(error)
+		if code == 0 {
+			return err
+		}
+		return &ExitError{
+			Code: code,
+			Err:  err,
+		}
 	}
 
 	return fmt.Errorf("%w unexpected returned value %s %#v", ErrCallFailure, s.flagSet.Name(), resultValues)
 }
+
+// parseFlags parses arguments and handles the error according to ErrorHandling.
+func (s *targetFunction) parseFlags(ctx context.Context, flagSet *flag.FlagSet, arguments []string) error {
+	err := flagSet.Parse(arguments)
+	if err == nil {
+		return nil
+	}
+	errorHandling := s.config.ErrorHandling.Get()
+	if isNoExit(ctx) {
+		errorHandling = flag.ContinueOnError
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		if errorHandling == flag.ExitOnError {
+			os.Exit(0)
+		}
+		return fmt.Errorf("%w %s", err, s.Name())
+	}
+	err = s.newParseError(err)
+	switch errorHandling {
+	case flag.ExitOnError:
+		writeSuggestions(flagSet.Output(), err)
+		os.Exit(2)
+	case flag.PanicOnError:
+		panic(err)
+	}
+	return err
+}
+
+func (s *targetFunction) newParseError(err error) error {
+	msg := err.Error()
+	if !strings.HasPrefix(msg, undefinedFlagErrorPrefix) {
+		return fmt.Errorf("%w err %v", ErrParseFailure, err)
+	}
+
+	name := strings.TrimLeft(strings.TrimPrefix(msg, undefinedFlagErrorPrefix), "-")
+	names := []string{}
+	s.flagSet.VisitAll(func(f *flag.Flag) {
+		names = append(names, f.Name)
+	})
+	return &UnknownFlagError{
+		Command:     s.Name(),
+		Name:        name,
+		Suggestions: suggest.Rank(name, names),
+	}
+}
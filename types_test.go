@@ -258,3 +258,164 @@ func TestTargetFunctionCall(t *testing.T) {
 		})
 	}
 }
+
+func unknownFlagTarget(name string, count int) {}
+
+func TestTargetFunctionUnknownFlag(t *testing.T) {
+	s, err := fcli.NewTargetFunction(unknownFlagTarget, fcli.WithErrorHandling(flag.ContinueOnError))
+	if !assert.Nil(t, err) {
+		return
+	}
+	err = s.Call([]string{"-nmae", "x"})
+	assert.ErrorIs(t, err, fcli.ErrCallFailure)
+	var e *fcli.UnknownFlagError
+	if !assert.ErrorAs(t, err, &e) {
+		return
+	}
+	assert.Equal(t, "nmae", e.Name)
+	assert.Equal(t, []string{"name"}, e.Suggestions)
+}
+
+var errReentrantTarget = errors.New("reentrant target")
+
+func reentrantTarget(name string, n int) error {
+	if name != fmt.Sprint(n) {
+		return fmt.Errorf("%w name %q n %d", errReentrantTarget, name, n)
+	}
+	return nil
+}
+
+func TestTargetFunctionCallReentrant(t *testing.T) {
+	s, err := fcli.NewTargetFunction(reentrantTarget, fcli.WithErrorHandling(flag.ContinueOnError))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	t.Run("sequential", func(t *testing.T) {
+		assert.Nil(t, s.Call([]string{"-name", "1", "-n", "1"}))
+		// the values of the previous call are not reused
+		assert.Nil(t, s.Call([]string{"-name", "0"}))
+		assert.ErrorIs(t, s.Call([]string{"-n", "1"}), errReentrantTarget)
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		var (
+			wg   sync.WaitGroup
+			errC = make(chan error, 100)
+		)
+		for i := 0; i < 100; i++ {
+			i := i
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errC <- s.CallWithContext(context.Background(), []string{"-name", fmt.Sprint(i), "-n", fmt.Sprint(i)})
+			}()
+		}
+		wg.Wait()
+		close(errC)
+		for err := range errC {
+			assert.Nil(t, err)
+		}
+	})
+}
+
+// paramNamesTarget has the doc.
+func paramNamesTarget(name string, count int) {}
+
+func unnamedParams(int, string) {}
+
+func TestTargetFunctionParamNames(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		f         any
+		opt       []fcli.Option
+		wantNames []string
+		wantDoc   string
+		err       error
+	}{
+		{
+			name:      "func decl",
+			f:         paramNamesTarget,
+			wantNames: []string{"name", "count"},
+			wantDoc:   "paramNamesTarget has the doc.\n",
+		},
+		{
+			name:      "func decl with param names",
+			f:         paramNamesTarget,
+			opt:       []fcli.Option{fcli.WithParamNames([]string{"user", "times"})},
+			wantNames: []string{"user", "times"},
+			wantDoc:   "paramNamesTarget has the doc.\n",
+		},
+		{
+			name:      "func decl with doc",
+			f:         paramNamesTarget,
+			opt:       []fcli.Option{fcli.WithDoc("overridden")},
+			wantNames: []string{"name", "count"},
+			wantDoc:   "overridden\n",
+		},
+		{
+			name:      "unnamed params",
+			f:         unnamedParams,
+			wantNames: []string{"arg0", "arg1"},
+		},
+		{
+			name:      "literal",
+			f:         func(ctx context.Context, x int, y bool) {},
+			wantNames: []string{"arg0", "arg1"},
+		},
+		{
+			name: "literal with param names and doc",
+			f:    func(ctx context.Context, x int, y bool) {},
+			opt: []fcli.Option{
+				fcli.WithParamNames([]string{"count", "verbose"}),
+				fcli.WithDoc("literal"),
+			},
+			wantNames: []string{"count", "verbose"},
+			wantDoc:   "literal\n",
+		},
+		{
+			name: "too few param names",
+			f:    func(x int, y bool) {},
+			opt:  []fcli.Option{fcli.WithParamNames([]string{"count"})},
+			err:  fcli.ErrBadTargetFunction,
+		},
+		{
+			name: "duplicate param names",
+			f:    func(x int, y bool) {},
+			opt:  []fcli.Option{fcli.WithParamNames([]string{"x", "x"})},
+			err:  fcli.ErrBadTargetFunction,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := fcli.NewTargetFunction(tc.f, tc.opt...)
+			assert.ErrorIs(t, err, tc.err)
+			if tc.err != nil {
+				return
+			}
+			names := []string{}
+			for _, p := range s.Params() {
+				names = append(names, p.Name)
+			}
+			assert.Equal(t, tc.wantNames, names)
+			assert.Equal(t, tc.wantDoc, s.Doc())
+		})
+	}
+}
+
+func TestTargetFunctionLiteralCall(t *testing.T) {
+	var got []any
+	s, err := fcli.NewTargetFunction(func(name string, count int) {
+		got = []any{name, count}
+	},
+		fcli.WithCommandName("greet"),
+		fcli.WithParamNames([]string{"name", "count"}),
+		fcli.WithErrorHandling(flag.ContinueOnError),
+	)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, "greet", s.Name())
+	assert.Nil(t, s.Call([]string{"-name", "alice", "-count", "2"}))
+	assert.Equal(t, []any{"alice", 2}, got)
+}
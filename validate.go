@@ -0,0 +1,180 @@
+package fcli
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrCLIInvalid = errors.New("invalid cli")
+)
+
+// reservedCommandNames are dispatched before the commands.
+var reservedCommandNames = []string{helpCommandName, completeCommandName, docsCommandName, specCommandName}
+
+// reservedFlagNames request the usage of the function.
+var reservedFlagNames = []string{"h", "help"}
+
+func isReserved(reserved []string, name string) bool {
+	for _, x := range reserved {
+		if x == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidationError is the error returned by Validate.
+type ValidationError struct {
+	// Problems is the descriptions of the clashes.
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%v %s", ErrCLIInvalid, strings.Join(e.Problems, "; "))
+}
+
+func (e *ValidationError) Unwrap() error { return ErrCLIInvalid }
+
+func (s *cliMap) Validate() error {
+	cliMu.RLock()
+	defer cliMu.RUnlock()
+	if problems := s.validate(); len(problems) > 0 {
+		return &ValidationError{
+			Problems: problems,
+		}
+	}
+	return nil
+}
+
+// validate returns the problems of s and the groups.
+// Requires cliMu.
+func (s *cliMap) validate() []string {
+	var (
+		problems = []string{}
+		path     = s.path()
+		report   = func(format string, v ...any) {
+			problems = append(problems, fmt.Sprintf("%s: %s", path, fmt.Sprintf(format, v...)))
+		}
+		names = make([]string, len(s.names))
+	)
+	copy(names, s.names)
+	sort.Strings(names)
+
+	for _, name := range names {
+		if isReserved(reservedCommandNames, name) {
+			report("command %s is reserved", name)
+		}
+		_, isCommand := s.commands[name]
+		if _, isGroup := s.groups[name]; isGroup && isCommand {
+			report("command %s clashes with group %s", name, name)
+		}
+	}
+
+	if s.defaultCommand != "" && !s.isCommandName(s.defaultCommand) {
+		report("default command %s is not found", s.defaultCommand)
+	}
+
+	topics := make([]string, 0, len(s.topics))
+	for k := range s.topics {
+		topics = append(topics, k)
+	}
+	sort.Strings(topics)
+	for _, topic := range topics {
+		switch {
+		case s.commands[topic] != nil:
+			report("help topic %s clashes with command %s", topic, topic)
+		case s.groups[topic] != nil:
+			report("help topic %s clashes with group %s", topic, topic)
+		case s.aliases[topic] != "":
+			report("help topic %s clashes with alias of %s", topic, s.aliases[topic])
+		}
+	}
+
+	for _, x := range s.problems {
+		report("%s", x)
+	}
+
+	globals := s.globalFlagTypes()
+	for _, g := range s.globals {
+		if isReserved(reservedFlagNames, g.name) {
+			report("global flag -%s is reserved", g.name)
+		}
+	}
+
+	for _, name := range names {
+		cmd, ok := s.commands[name]
+		if !ok {
+			continue
+		}
+		for _, alias := range cmd.Aliases() {
+			switch {
+			case isReserved(reservedCommandNames, alias):
+				report("alias %s of %s is reserved", alias, name)
+			case s.commands[alias] != nil:
+				report("alias %s of %s clashes with command %s", alias, name, alias)
+			case s.groups[alias] != nil:
+				report("alias %s of %s clashes with group %s", alias, name, alias)
+			case s.aliases[alias] != name:
+				report("alias %s of %s clashes with alias of %s", alias, name, s.aliases[alias])
+			}
+		}
+		for i, f := range cmd.flags {
+			if isReserved(reservedFlagNames, f.Name()) {
+				report("flag -%s of %s is reserved", f.Name(), name)
+			}
+			if t, ok := globals[f.Name()]; ok && t != cmd.flagTypes[i] {
+				report("flag -%s %v of %s clashes with global flag -%s %v", f.Name(), cmd.flagTypes[i], name, f.Name(), t)
+			}
+		}
+		for _, x := range sortedKeys(cmd.config.RenamedFlags.Get()) {
+			newName := cmd.config.RenamedFlags.Get()[x]
+			switch {
+			case cmd.flagSet.Lookup(newName) == nil:
+				report("renamed flag -%s of %s: flag -%s not found", x, name, newName)
+			case cmd.flagSet.Lookup(x) != nil:
+				report("renamed flag -%s of %s clashes with flag -%s", x, name, x)
+			case isReserved(reservedFlagNames, x):
+				report("renamed flag -%s of %s is reserved", x, name)
+			}
+		}
+		for _, x := range sortedKeys(cmd.config.DeprecatedFlags.Get()) {
+			if cmd.flagSet.Lookup(x) == nil {
+				report("deprecated flag -%s of %s not found", x, name)
+			}
+		}
+	}
+
+	for _, name := range names {
+		if g, ok := s.groups[name]; ok {
+			problems = append(problems, g.validate()...)
+		}
+	}
+	return problems
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// globalFlagTypes returns the types of the global flags of s and the parents by the names.
+// The nearest wins.
+func (s *cliMap) globalFlagTypes() map[string]reflect.Type {
+	r := map[string]reflect.Type{}
+	for x := s; x != nil; x = x.parent {
+		for _, g := range x.globals {
+			if _, ok := r[g.name]; !ok {
+				r[g.name] = g.typ
+			}
+		}
+	}
+	return r
+}
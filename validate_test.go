@@ -0,0 +1,126 @@
+package fcli_test
+
+import (
+	"testing"
+
+	"github.com/berquerant/fcli"
+	"github.com/stretchr/testify/assert"
+)
+
+func validateTestGreet(name string) {}
+
+func validateTestHelp(h bool) {}
+
+func validateTestVerbose(v int) {}
+
+func TestCLIValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		setup func(t *testing.T, cli fcli.CLI)
+		want  []string
+	}{
+		{
+			name: "valid",
+			setup: func(t *testing.T, cli fcli.CLI) {
+				assert.Nil(t, cli.AddGlobalFlag("name", "", ""))
+				assert.Nil(t, cli.Add(validateTestGreet, fcli.WithAliases([]string{"g"})))
+				assert.Nil(t, cli.Group("db").Add(validateTestGreet, fcli.WithAliases([]string{"g"})))
+			},
+		},
+		{
+			name: "reserved command",
+			setup: func(t *testing.T, cli fcli.CLI) {
+				assert.Nil(t, cli.Add(validateTestGreet, fcli.WithCommandName("help")))
+				assert.Nil(t, cli.Group("g").Add(validateTestGreet, fcli.WithCommandName("__complete")))
+			},
+			want: []string{
+				"tool: command help is reserved",
+				"tool g: command __complete is reserved",
+			},
+		},
+		{
+			name: "command and group",
+			setup: func(t *testing.T, cli fcli.CLI) {
+				assert.Nil(t, cli.Add(validateTestGreet))
+				_ = cli.Group("validateTestGreet")
+			},
+			want: []string{
+				"tool: group validateTestGreet clashes with command validateTestGreet, not added",
+			},
+		},
+		{
+			name: "aliases",
+			setup: func(t *testing.T, cli fcli.CLI) {
+				_ = cli.Group("db")
+				assert.Nil(t, cli.Add(validateTestGreet, fcli.WithCommandName("greet"), fcli.WithAliases([]string{"g", "db", "help"})))
+				assert.Nil(t, cli.Add(validateTestGreet, fcli.WithCommandName("grep"), fcli.WithAliases([]string{"g", "greet"})))
+			},
+			want: []string{
+				"tool: alias g of greet clashes with alias of grep",
+				"tool: alias db of greet clashes with group db",
+				"tool: alias help of greet is reserved",
+				"tool: alias greet of grep clashes with command greet",
+			},
+		},
+		{
+			name: "help topics",
+			setup: func(t *testing.T, cli fcli.CLI) {
+				assert.Nil(t, cli.Add(validateTestGreet, fcli.WithCommandName("greet"), fcli.WithAliases([]string{"g"})))
+				_ = cli.Group("db")
+				cli.AddHelpTopic("greet", "topic")
+				cli.AddHelpTopic("g", "topic")
+				cli.AddHelpTopic("db", "topic")
+				cli.AddHelpTopic("config", "topic")
+			},
+			want: []string{
+				"tool: help topic db clashes with group db",
+				"tool: help topic g clashes with alias of greet",
+				"tool: help topic greet clashes with command greet",
+			},
+		},
+		{
+			name: "default",
+			setup: func(t *testing.T, cli fcli.CLI) {
+				assert.Nil(t, cli.Add(validateTestGreet, fcli.WithCommandName("greet"), fcli.WithAliases([]string{"g"})))
+				cli.Default("g")
+				g := cli.Group("db")
+				assert.Nil(t, g.Add(validateTestGreet, fcli.WithCommandName("dump")))
+				g.Default("load")
+			},
+			want: []string{
+				"tool db: default command load is not found",
+			},
+		},
+		{
+			name: "flags",
+			setup: func(t *testing.T, cli fcli.CLI) {
+				assert.Nil(t, cli.AddGlobalFlag("help", false, ""))
+				assert.Nil(t, cli.AddGlobalFlag("v", false, ""))
+				assert.Nil(t, cli.Add(validateTestHelp))
+				assert.Nil(t, cli.Group("g").Add(validateTestVerbose))
+			},
+			want: []string{
+				"tool: global flag -help is reserved",
+				"tool: flag -h of validateTestHelp is reserved",
+				"tool g: flag -v int of validateTestVerbose clashes with global flag -v bool",
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			cli := fcli.NewCLI("tool")
+			tc.setup(t, cli)
+			err := cli.Validate()
+			if len(tc.want) == 0 {
+				assert.Nil(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, fcli.ErrCLIInvalid)
+			var e *fcli.ValidationError
+			if !assert.ErrorAs(t, err, &e) {
+				return
+			}
+			assert.Equal(t, tc.want, e.Problems)
+		})
+	}
+}